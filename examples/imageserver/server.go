@@ -43,17 +43,9 @@ var cnames map[string]webcam.ControlID = map[string]webcam.ControlID{
 
 func main() {
 	flag.Parse()
-	s := strings.Split(*resolution, "x")
-	if len(s) != 2 {
-		log.Fatalf("%s: Illegal resolution", *resolution)
-	}
-	x, err := strconv.Atoi(s[0])
-	if err != nil {
-		log.Fatalf("%s: illegal width: %v", s[0], err)
-	}
-	y, err := strconv.Atoi(s[1])
+	x, y, err := snapshot.ParseResolution(*resolution)
 	if err != nil {
-		log.Fatalf("%s: illegal height: %v", s[1], err)
+		log.Fatal(err)
 	}
 	if *controls == "list" {
 		fmt.Printf("Control list (not all cameras may support all options):\n")
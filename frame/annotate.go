@@ -0,0 +1,61 @@
+package frame
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Corner selects where an annotation is drawn.
+type Corner int
+
+const (
+	TopLeft Corner = iota
+	TopRight
+	BottomLeft
+	BottomRight
+)
+
+// Padding around annotation text, in pixels.
+const annotatePad = 4
+
+// AnnotateMetadata returns a copy of the frame with the capture timestamp,
+// sequence number and resolution drawn as text in the selected corner,
+// on a dark background so that the text is readable on any image.
+// If face is nil, basicfont.Face7x13 is used.
+func AnnotateMetadata(f Frame, face *basicfont.Face, corner Corner, col color.Color) *image.RGBA {
+	if face == nil {
+		face = basicfont.Face7x13
+	}
+	dst := toRGBA(f)
+	b := dst.Bounds()
+	ts := "-"
+	if t := TimestampOf(f); !t.IsZero() {
+		ts = t.Format("2006-01-02 15:04:05.000")
+	}
+	text := fmt.Sprintf("%s #%d %dx%d", ts, SequenceOf(f), b.Dx(), b.Dy())
+	d := &font.Drawer{Dst: dst, Src: image.NewUniform(col), Face: face}
+	tw := d.MeasureString(text).Ceil()
+	th := face.Height
+	// Position the background box in the corner.
+	box := image.Rect(0, 0, tw+2*annotatePad, th+2*annotatePad)
+	switch corner {
+	case TopRight:
+		box = box.Add(image.Pt(b.Max.X-box.Dx(), b.Min.Y))
+	case BottomLeft:
+		box = box.Add(image.Pt(b.Min.X, b.Max.Y-box.Dy()))
+	case BottomRight:
+		box = box.Add(b.Max.Sub(box.Size()))
+	default:
+		box = box.Add(b.Min)
+	}
+	draw.Draw(dst, box, image.NewUniform(color.RGBA{0, 0, 0, 0xA0}), image.Point{}, draw.Over)
+	d.Dot = fixed.P(box.Min.X+annotatePad, box.Min.Y+annotatePad+face.Ascent)
+	d.DrawString(text)
+	return dst
+}
@@ -0,0 +1,101 @@
+package frame
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// Characters of increasing density used to represent luminance.
+const ansiRamp = " .:-=+*#%@"
+
+// RenderANSI returns a text preview of the image, cols characters wide,
+// suitable for printing to a terminal. Each character represents the
+// average luminance of a block of pixels. Since terminal cells are
+// about twice as tall as they are wide, each row covers twice the
+// height of a column to preserve the aspect ratio.
+func RenderANSI(img image.Image, cols int) string {
+	b := img.Bounds()
+	if cols <= 0 || b.Empty() {
+		return ""
+	}
+	rows := ansiRows(b, cols, 2)
+	g := LuminanceImage(img)
+	var sb strings.Builder
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			cell := ansiCell(b, cols, rows, c, r)
+			var sum, n int
+			for y := cell.Min.Y; y < cell.Max.Y; y++ {
+				for x := cell.Min.X; x < cell.Max.X; x++ {
+					sum += int(g.Pix[g.PixOffset(x, y)])
+					n++
+				}
+			}
+			sb.WriteByte(ansiRamp[sum/n*len(ansiRamp)/256])
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// RenderANSIColor is like RenderANSI, but uses 24-bit ANSI colour.
+// Each character is a half block whose foreground and background colours
+// are the upper and lower halves of the cell, doubling the vertical
+// resolution.
+func RenderANSIColor(img image.Image, cols int) string {
+	b := img.Bounds()
+	if cols <= 0 || b.Empty() {
+		return ""
+	}
+	rows := ansiRows(b, cols, 2)
+	var sb strings.Builder
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			// Each half is one row of a grid with twice the rows.
+			top := ansiColor(img, ansiCell(b, cols, rows*2, c, r*2))
+			bot := ansiColor(img, ansiCell(b, cols, rows*2, c, r*2+1))
+			fmt.Fprintf(&sb, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀", top.R, top.G, top.B, bot.R, bot.G, bot.B)
+		}
+		sb.WriteString("\x1b[0m\n")
+	}
+	return sb.String()
+}
+
+// ansiRows returns the number of rows for the columns, where
+// cells are aspect times taller than they are wide.
+func ansiRows(b image.Rectangle, cols, aspect int) int {
+	rows := (b.Dy()*cols + b.Dx()*aspect/2) / (b.Dx() * aspect)
+	if rows < 1 {
+		rows = 1
+	}
+	if rows > b.Dy() {
+		rows = b.Dy()
+	}
+	return rows
+}
+
+// ansiCell returns the pixels covered by a cell of a cols by rows grid.
+// Cells always cover at least one pixel.
+func ansiCell(b image.Rectangle, cols, rows, c, r int) image.Rectangle {
+	x0 := b.Min.X + c*b.Dx()/cols
+	x1 := b.Min.X + (c+1)*b.Dx()/cols
+	y0 := b.Min.Y + r*b.Dy()/rows
+	y1 := b.Min.Y + (r+1)*b.Dy()/rows
+	if x1 <= x0 {
+		x1 = x0 + 1
+	}
+	if y1 <= y0 {
+		y1 = y0 + 1
+	}
+	return image.Rect(x0, y0, x1, y1).Intersect(b)
+}
+
+// ansiColor returns the colour at the centre of the cell.
+func ansiColor(img image.Image, cell image.Rectangle) color.RGBA {
+	if cell.Empty() {
+		return color.RGBA{}
+	}
+	return color.RGBAModel.Convert(img.At((cell.Min.X+cell.Max.X)/2, (cell.Min.Y+cell.Max.Y)/2)).(color.RGBA)
+}
@@ -0,0 +1,26 @@
+package frame
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// ScaleAspect returns a copy of the image rescaled to square pixels,
+// where num/den is the pixel aspect ratio (the height / width of a pixel,
+// as reported by V4L2). The height is kept and the width scaled, so
+// e.g NTSC frames with an aspect of 11/10 become narrower.
+// If the ratio is not valid or is 1, the image is copied unscaled.
+func ScaleAspect(img image.Image, num, den int) *image.RGBA {
+	b := img.Bounds()
+	if num <= 0 || den <= 0 || num == den {
+		return toRGBA(img)
+	}
+	w := (b.Dx()*den + num/2) / num
+	if w < 1 {
+		w = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, b.Dy()))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), AsDrawSource(img), b, draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,108 @@
+package frame
+
+import (
+	"image"
+	"image/color"
+)
+
+// BayerPattern is the order of the colour filters of a Bayer sensor,
+// starting at the top left pixel.
+type BayerPattern int
+
+const (
+	BayerRGGB BayerPattern = iota
+	BayerBGGR
+	BayerGRBG
+	BayerGBRG
+)
+
+// cfa returns the colour (0 = red, 1 = green, 2 = blue) of each
+// pixel of the 2x2 pattern, in row order.
+func (p BayerPattern) cfa() [4]byte {
+	switch p {
+	case BayerBGGR:
+		return [4]byte{2, 1, 1, 0}
+	case BayerGRBG:
+		return [4]byte{1, 0, 2, 1}
+	case BayerGBRG:
+		return [4]byte{1, 2, 0, 1}
+	default:
+		return [4]byte{0, 1, 1, 2}
+	}
+}
+
+type fBayer struct {
+	pattern BayerPattern
+	b       image.Rectangle
+	stride  int
+	frame   []byte
+	Base
+}
+
+// Register framers for the 8 bit Bayer formats.
+func init() {
+	RegisterFramer("RGGB", newBayerFramer(BayerRGGB))
+	RegisterFramer("BA81", newBayerFramer(BayerBGGR))
+	RegisterFramer("GRBG", newBayerFramer(BayerGRBG))
+	RegisterFramer("GBRG", newBayerFramer(BayerGBRG))
+}
+
+// Return a framer factory for 8 bit Bayer data with the pattern.
+func newBayerFramer(p BayerPattern) func(w, h, stride, size int) func([]byte, func()) (Frame, error) {
+	return func(w, h, stride, size int) func([]byte, func()) (Frame, error) {
+		// All the 8 bit patterns have the layout of RGGB.
+		if err := checkLayout("RGGB", w, h, stride, size); err != nil {
+			return failFramer(err)
+		}
+		return func(b []byte, rel func()) (Frame, error) {
+			if err := CheckFrame(b, size, rel); err != nil {
+				return nil, err
+			}
+			f := &fBayer{pattern: p, b: image.Rect(0, 0, w, h), stride: stride, frame: b, Base: NewBase(b, rel)}
+			SetReleaseFinalizer(f)
+			return f, nil
+		}
+	}
+}
+
+func (f *fBayer) ColorModel() color.Model {
+	return color.RGBAModel
+}
+
+func (f *fBayer) Bounds() image.Rectangle {
+	return f.b
+}
+
+// At returns the colour of the 2x2 block containing the pixel, which is
+// a simple demosaic suitable for previews. For full quality, use EncodeDNG
+// and demosaic with a raw converter.
+func (f *fBayer) At(x, y int) color.Color {
+	x0, y0 := x&^1, y&^1
+	if x0+1 >= f.b.Max.X {
+		x0 = f.b.Max.X - 2
+	}
+	if y0+1 >= f.b.Max.Y {
+		y0 = f.b.Max.Y - 2
+	}
+	if x0 < 0 || y0 < 0 {
+		v := f.frame[f.stride*y+x]
+		return color.RGBA{v, v, v, 0xFF}
+	}
+	var rgb [3]int
+	var n [3]int
+	for i, c := range f.pattern.cfa() {
+		rgb[c] += int(f.frame[f.stride*(y0+i/2)+x0+i%2])
+		n[c]++
+	}
+	return color.RGBA{uint8(rgb[0] / n[0]), uint8(rgb[1] / n[1]), uint8(rgb[2] / n[2]), 0xFF}
+}
+
+// BayerPattern returns the colour filter pattern of the frame.
+func (f *fBayer) BayerPattern() BayerPattern {
+	return f.pattern
+}
+
+// rawPlane returns the raw frame data and its stride.
+func (f *fBayer) rawPlane() ([]byte, int) {
+	return f.frame, f.stride
+}
@@ -0,0 +1,49 @@
+package frame
+
+import (
+	"fmt"
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// ContactSheet returns an image with thumbnails of the frames laid out
+// in a grid of cols columns, in order left to right and top to bottom,
+// e.g to review a burst of frames. Each cell is thumbW by thumbH pixels,
+// and each frame is scaled to fit its cell, preserving the aspect ratio,
+// and centred on a black background. The last row may be partly empty.
+// The frames are not released.
+func ContactSheet(frames []Frame, cols int, thumbW, thumbH int) (*image.RGBA, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("ContactSheet: no frames")
+	}
+	if cols <= 0 || thumbW <= 0 || thumbH <= 0 {
+		return nil, fmt.Errorf("ContactSheet: illegal layout %d columns of %dx%d", cols, thumbW, thumbH)
+	}
+	if cols > len(frames) {
+		cols = len(frames)
+	}
+	rows := (len(frames) + cols - 1) / cols
+	dst := image.NewRGBA(image.Rect(0, 0, cols*thumbW, rows*thumbH))
+	draw.Draw(dst, dst.Bounds(), image.Black, image.Point{}, draw.Src)
+	for i, f := range frames {
+		if e, ok := f.(Decoder); ok {
+			if err := e.Err(); err != nil {
+				return nil, fmt.Errorf("ContactSheet: frame %d: %v", i, err)
+			}
+		}
+		src := AsDrawSource(f)
+		b := src.Bounds()
+		if b.Empty() {
+			continue
+		}
+		w, h := thumbW, max1(b.Dy()*thumbW/b.Dx())
+		if h > thumbH {
+			w, h = max1(b.Dx()*thumbH/b.Dy()), thumbH
+		}
+		cell := image.Pt((i%cols)*thumbW, (i/cols)*thumbH)
+		min := cell.Add(image.Pt((thumbW-w)/2, (thumbH-h)/2))
+		draw.ApproxBiLinear.Scale(dst, image.Rectangle{min, min.Add(image.Pt(w, h))}, src, b, draw.Src, nil)
+	}
+	return dst, nil
+}
@@ -0,0 +1,177 @@
+package frame
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// RGBAWriter is implemented by frames that can convert themselves
+// to RGBA more efficiently than by calling At for every pixel.
+type RGBAWriter interface {
+	// WriteRGBA writes the frame into dst, which must contain
+	// the bounds of the frame.
+	WriteRGBA(dst *image.RGBA) error
+}
+
+// checkDst verifies that the destination image can hold the frame.
+func checkDst(dst *image.RGBA, b image.Rectangle) error {
+	if !b.In(dst.Bounds()) {
+		return fmt.Errorf("destination %v does not contain frame %v", dst.Bounds(), b)
+	}
+	return nil
+}
+
+// ycbcrToRGB converts a full range (JFIF) YCbCr value to RGB using
+// 16.16 fixed point arithmetic. The coefficients are those
+// of BT.601 scaled by 65536:
+//
+//	R = Y + 1.40200 * Cr
+//	G = Y - 0.34414 * Cb - 0.71414 * Cr
+//	B = Y + 1.77200 * Cb
+func ycbcrToRGB(y, cb, cr uint8) (uint8, uint8, uint8) {
+	yy := int32(y)<<16 + 1<<15
+	cb1 := int32(cb) - 128
+	cr1 := int32(cr) - 128
+	r := clamp8((yy + 91881*cr1) >> 16)
+	g := clamp8((yy - 22554*cb1 - 46802*cr1) >> 16)
+	b := clamp8((yy + 116130*cb1) >> 16)
+	return r, g, b
+}
+
+func clamp8(v int32) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
+
+// copyRGBA converts the image to RGBA and copies the pixels to dst.
+func copyRGBA(img image.Image, dst []byte) (int, error) {
+	sz := img.Bounds().Size()
+	n := sz.X * sz.Y * 4
+	if len(dst) < n {
+		return 0, fmt.Errorf("buffer too small (need %d, have %d)", n, len(dst))
+	}
+	return copy(dst, toRGBA(img).Pix), nil
+}
+
+// rgbLayout is the size of a pixel of a packed RGB format, and the offsets
+// of its components. a is -1 if there is no alpha channel.
+type rgbLayout struct {
+	pixel, r, g, b, a int
+}
+
+// Layouts of the packed RGB formats, matching their framers.
+var rgbLayouts = map[FourCC]rgbLayout{
+	"RGB3": {3, 0, 1, 2, -1},
+	"BGR3": {3, 2, 1, 0, -1},
+	"XR24": {4, 2, 1, 0, -1},
+	"BX24": {4, 1, 2, 3, -1},
+	"AR24": {4, 2, 1, 0, 3},
+	"AB24": {4, 0, 1, 2, 3},
+	"BA24": {4, 1, 2, 3, 0},
+}
+
+// Convert returns a copy of the frame in the byte layout of another format,
+// so that frames from cameras with different native formats can be handled
+// uniformly. The packed RGB formats, YUYV and GREY are supported as
+// targets. The new frame has the timestamp and sequence number of f,
+// and does not refer to the memory of f, so f may be released.
+func Convert(f Frame, to FourCC) (Frame, error) {
+	b := f.Bounds()
+	if b.Empty() {
+		return nil, ErrNotDecodable
+	}
+	src := toRGBA(f)
+	w, h := b.Dx(), b.Dy()
+	var buf []byte
+	var stride int
+	if l, ok := rgbLayouts[to]; ok {
+		stride = w * l.pixel
+		buf = packRGB(src, l, stride)
+	} else {
+		switch to {
+		case "YUYV":
+			stride = ((w + 1) &^ 1) * 2
+			buf = packYUYV(src, stride)
+		case "GREY":
+			stride = w
+			buf = packGrey(src, stride)
+		default:
+			return nil, fmt.Errorf("%s: conversion not supported", to)
+		}
+	}
+	framer, err := GetFramer(to, w, h, stride, len(buf), WithTimestamp(TimestampOf(f)), WithSequence(SequenceOf(f)), WithTag(TagOf(f)))
+	if err != nil {
+		return nil, err
+	}
+	return framer(buf, nil)
+}
+
+// packRGB converts the image to a packed RGB layout.
+// The alpha is stored without premultiplication.
+func packRGB(src *image.RGBA, l rgbLayout, stride int) []byte {
+	sz := src.Rect.Size()
+	buf := make([]byte, stride*sz.Y)
+	for y := 0; y < sz.Y; y++ {
+		s := src.Pix[y*src.Stride:]
+		d := buf[y*stride:]
+		for x := 0; x < sz.X; x++ {
+			r, g, b, a := s[x*4], s[x*4+1], s[x*4+2], s[x*4+3]
+			if l.a >= 0 {
+				if a != 0 && a != 0xFF {
+					r = uint8(uint32(r) * 0xFF / uint32(a))
+					g = uint8(uint32(g) * 0xFF / uint32(a))
+					b = uint8(uint32(b) * 0xFF / uint32(a))
+				}
+				d[x*l.pixel+l.a] = a
+			}
+			d[x*l.pixel+l.r] = r
+			d[x*l.pixel+l.g] = g
+			d[x*l.pixel+l.b] = b
+		}
+	}
+	return buf
+}
+
+// packYUYV converts the image to YUYV, averaging the chroma of each
+// pair of pixels. If the width is odd, the last pixel is repeated.
+func packYUYV(src *image.RGBA, stride int) []byte {
+	sz := src.Rect.Size()
+	buf := make([]byte, stride*sz.Y)
+	for y := 0; y < sz.Y; y++ {
+		s := src.Pix[y*src.Stride:]
+		d := buf[y*stride:]
+		for x := 0; x < sz.X; x += 2 {
+			x1 := x + 1
+			if x1 == sz.X {
+				x1 = x
+			}
+			y0, cb0, cr0 := color.RGBToYCbCr(s[x*4], s[x*4+1], s[x*4+2])
+			y1, cb1, cr1 := color.RGBToYCbCr(s[x1*4], s[x1*4+1], s[x1*4+2])
+			d[x*2] = y0
+			d[x*2+1] = uint8((uint16(cb0) + uint16(cb1) + 1) / 2)
+			d[x*2+2] = y1
+			d[x*2+3] = uint8((uint16(cr0) + uint16(cr1) + 1) / 2)
+		}
+	}
+	return buf
+}
+
+// packGrey converts the image to 8 bit luminance.
+func packGrey(src *image.RGBA, stride int) []byte {
+	sz := src.Rect.Size()
+	buf := make([]byte, stride*sz.Y)
+	for y := 0; y < sz.Y; y++ {
+		s := src.Pix[y*src.Stride:]
+		d := buf[y*stride:]
+		for x := 0; x < sz.X; x++ {
+			d[x], _, _ = color.RGBToYCbCr(s[x*4], s[x*4+1], s[x*4+2])
+		}
+	}
+	return buf
+}
@@ -0,0 +1,178 @@
+package frame
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// yuyvGradient returns a YUYV frame of a gradient in all three components.
+func yuyvGradient(t testing.TB, w, h int) Frame {
+	stride := ((w + 1) &^ 1) * 2
+	b := make([]byte, stride*h)
+	for y := 0; y < h; y++ {
+		row := b[y*stride:]
+		for x := 0; x < stride/2; x++ {
+			row[x*2] = uint8((x + y) * 255 / (w + h))
+			if x&1 == 0 {
+				row[x*2+1] = uint8(x * 255 / w)
+			} else {
+				row[x*2+1] = uint8(255 - y*255/h)
+			}
+		}
+	}
+	f, err := frameYUYV422(len(b), stride, w, h, b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+func TestWriteRGBAMatchesAt(t *testing.T) {
+	for _, sz := range []image.Point{{16, 8}, {15, 7}, {1, 3}} {
+		f := yuyvGradient(t, sz.X, sz.Y)
+		dst := image.NewRGBA(f.Bounds())
+		if err := f.(RGBAWriter).WriteRGBA(dst); err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < sz.Y; y++ {
+			for x := 0; x < sz.X; x++ {
+				want := color.RGBAModel.Convert(f.At(x, y)).(color.RGBA)
+				got := dst.RGBAAt(x, y)
+				if absDiff(got.R, want.R) > 1 || absDiff(got.G, want.G) > 1 || absDiff(got.B, want.B) > 1 || got.A != 0xFF {
+					t.Fatalf("%v: pixel (%d,%d): got %v, want %v", sz, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestWriteRGBAMatchesAtRGB(t *testing.T) {
+	w, h := 7, 5
+	b := make([]byte, w*h*3)
+	for i := range b {
+		b[i] = uint8(i * 7)
+	}
+	framer, err := GetFramer("BGR3", w, h, w*3, len(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := framer(b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := image.NewRGBA(f.Bounds())
+	if err := f.(RGBAWriter).WriteRGBA(dst); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			want := color.RGBAModel.Convert(f.At(x, y)).(color.RGBA)
+			if got := dst.RGBAAt(x, y); got != want {
+				t.Fatalf("pixel (%d,%d): got %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestWriteRGBASmallDst(t *testing.T) {
+	f := yuyvGradient(t, 16, 8)
+	if err := f.(RGBAWriter).WriteRGBA(image.NewRGBA(image.Rect(0, 0, 8, 8))); err == nil {
+		t.Fatal("no error for destination smaller than the frame")
+	}
+}
+
+// floatYUYVToRGBA is the floating point conversion that WriteRGBA replaces.
+func floatYUYVToRGBA(f *fYUYV422, dst *image.RGBA) {
+	clamp := func(v float64) uint8 {
+		if v < 0 {
+			return 0
+		}
+		if v > 255 {
+			return 255
+		}
+		return uint8(v + 0.5)
+	}
+	for y := 0; y < f.b.Max.Y; y++ {
+		src := f.row(y)
+		d := dst.Pix[dst.PixOffset(0, y):]
+		for x := 0; x < f.b.Max.X; x++ {
+			u, v := chroma422(src, x)
+			yy, cb, cr := float64(src[x*2]), float64(u)-128, float64(v)-128
+			d[x*4] = clamp(yy + 1.402*cr)
+			d[x*4+1] = clamp(yy - 0.34414*cb - 0.71414*cr)
+			d[x*4+2] = clamp(yy + 1.772*cb)
+			d[x*4+3] = 0xFF
+		}
+	}
+}
+
+func BenchmarkYUYVWriteRGBA720p(b *testing.B) {
+	f := yuyvGradient(b, 1280, 720)
+	dst := image.NewRGBA(f.Bounds())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.(RGBAWriter).WriteRGBA(dst)
+	}
+}
+
+func BenchmarkYUYVFloat720p(b *testing.B) {
+	f := yuyvGradient(b, 1280, 720)
+	dst := image.NewRGBA(f.Bounds())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		floatYUYVToRGBA(f.(*fYUYV422), dst)
+	}
+}
+
+func TestConvertYUYVRoundTrip(t *testing.T) {
+	// A frame of in-gamut colors, so that the RGB3 frame is not clipped.
+	w, h := 8, 4
+	b := make([]byte, w*h*2)
+	for i := 0; i < len(b); i += 4 {
+		b[i], b[i+2] = uint8(64+i), uint8(66+i)
+		b[i+1], b[i+3] = uint8(96+i), uint8(160-i)
+	}
+	src, err := frameYUYV422(len(b), w*2, w, h, b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithSequence(42)(src)
+	rgb, err := Convert(src, "RGB3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rgb.Bounds() != src.Bounds() || SequenceOf(rgb) != 42 {
+		t.Fatalf("RGB3 bounds %v, sequence %d", rgb.Bounds(), SequenceOf(rgb))
+	}
+	if raw := RawOf(rgb); len(raw) != w*h*3 {
+		t.Fatalf("RGB3 frame has %d bytes", len(raw))
+	}
+	back, err := Convert(rgb, "YUYV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// The RGB3 frame has the pixels of the source, allowing
+			// for the rounding of the integer conversion.
+			want := color.RGBAModel.Convert(src.At(x, y)).(color.RGBA)
+			got := color.RGBAModel.Convert(rgb.At(x, y)).(color.RGBA)
+			if absDiff(got.R, want.R) > 1 || absDiff(got.G, want.G) > 1 || absDiff(got.B, want.B) > 1 {
+				t.Fatalf("RGB3 pixel (%d,%d): got %v, want %v", x, y, got, want)
+			}
+			// Converting back loses only rounding.
+			s, b := src.At(x, y).(color.YCbCr), back.At(x, y).(color.YCbCr)
+			if absDiff(s.Y, b.Y) > 2 || absDiff(s.Cb, b.Cb) > 2 || absDiff(s.Cr, b.Cr) > 2 {
+				t.Fatalf("YUYV pixel (%d,%d): got %v, want %v", x, y, b, s)
+			}
+		}
+	}
+}
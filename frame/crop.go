@@ -0,0 +1,129 @@
+package frame
+
+import (
+	"image"
+	"image/color"
+	"time"
+)
+
+// fCrop is a view of part of another frame.
+// As with image.SubImage, the bounds of the view retain the
+// coordinates of the source frame.
+type fCrop struct {
+	src Frame
+	r   image.Rectangle
+}
+
+// Crop returns a view of the part of the frame within r, without copying.
+// Releasing the view releases the source frame.
+func Crop(f Frame, r image.Rectangle) Frame {
+	return &fCrop{src: f, r: r.Intersect(f.Bounds())}
+}
+
+// CropCenter returns a view of the centre of the frame with
+// the given width and height (limited to the size of the frame).
+func CropCenter(f Frame, w, h int) Frame {
+	b := f.Bounds()
+	if w > b.Dx() {
+		w = b.Dx()
+	}
+	if h > b.Dy() {
+		h = b.Dy()
+	}
+	min := b.Min.Add(image.Pt((b.Dx()-w)/2, (b.Dy()-h)/2))
+	return Crop(f, image.Rectangle{min, min.Add(image.Pt(w, h))})
+}
+
+// CropToAspect returns a view of the centre of the frame that is the largest
+// that fits within the frame with an aspect ratio of num:den (width:height).
+func CropToAspect(f Frame, num, den int) Frame {
+	b := f.Bounds()
+	if num <= 0 || den <= 0 {
+		return Crop(f, b)
+	}
+	w, h := b.Dx(), b.Dy()
+	if w*den > h*num {
+		// Source is wider than the target.
+		w = h * num / den
+	} else {
+		h = w * den / num
+	}
+	return CropCenter(f, w, h)
+}
+
+func (f *fCrop) ColorModel() color.Model {
+	return f.src.ColorModel()
+}
+
+func (f *fCrop) Bounds() image.Rectangle {
+	return f.r
+}
+
+func (f *fCrop) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(f.r)) {
+		return color.RGBA{}
+	}
+	return f.src.At(x, y)
+}
+
+// LuminanceAt returns the luminance of the source pixel.
+func (f *fCrop) LuminanceAt(x, y int) uint8 {
+	if !(image.Point{x, y}.In(f.r)) {
+		return 0
+	}
+	return LuminanceAt(f.src, x, y)
+}
+
+// LuminanceImage returns the luminance of the view. If the source frame
+// has a fast path for its luminance, it is used and the view copied from it.
+func (f *fCrop) LuminanceImage() *image.Gray {
+	l, ok := f.src.(Luminancer)
+	if !ok {
+		return lumaSlow(f)
+	}
+	src := l.LuminanceImage()
+	g := image.NewGray(f.r)
+	if !f.r.Empty() {
+		copyPlane(g.Pix, g.Stride, src.Pix[src.PixOffset(f.r.Min.X, f.r.Min.Y):], src.Stride, f.r.Dx(), f.r.Dy())
+	}
+	return g
+}
+
+func (f *fCrop) Release() {
+	f.src.Release()
+}
+
+func (f *fCrop) Timestamp() time.Time {
+	return TimestampOf(f.src)
+}
+
+func (f *fCrop) Sequence() uint32 {
+	return SequenceOf(f.src)
+}
+
+// Err returns the error from decoding the source frame.
+func (f *fCrop) Err() error {
+	return DecodeErr(f.src)
+}
+
+func (f *fCrop) Tag() interface{} {
+	return TagOf(f.src)
+}
+
+func (f *fCrop) setTag(v interface{}) {
+	WithTag(v)(f.src)
+}
+
+// Raw returns the raw data of the whole source frame.
+func (f *fCrop) Raw() []byte {
+	return RawOf(f.src)
+}
+
+func (f *fCrop) Fingerprint() uint64 {
+	return FingerprintOf(f.src)
+}
+
+// CopyToBuffer copies the image to dst as RGBA.
+func (f *fCrop) CopyToBuffer(dst []byte) (int, error) {
+	return copyRGBA(f, dst)
+}
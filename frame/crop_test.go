@@ -0,0 +1,102 @@
+package frame
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// rampFrame returns an 8x4 GREY frame whose pixels are their offset.
+func rampFrame(t *testing.T, rel func()) Frame {
+	t.Helper()
+	pix := make([]byte, 8*4)
+	for i := range pix {
+		pix[i] = uint8(i)
+	}
+	framer, err := GetFramer("GREY", 8, 4, 8, len(pix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := framer(pix, rel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestCrop(t *testing.T) {
+	released := 0
+	f := rampFrame(t, func() { released++ })
+	for _, tc := range []struct {
+		r, want image.Rectangle
+	}{
+		{image.Rect(2, 1, 5, 3), image.Rect(2, 1, 5, 3)},
+		{image.Rect(-2, -2, 3, 2), image.Rect(0, 0, 3, 2)},
+		{image.Rect(6, 2, 20, 20), image.Rect(6, 2, 8, 4)},
+		{image.Rect(10, 10, 12, 12), image.Rectangle{}},
+		{image.Rect(3, 1, 3, 4), image.Rectangle{}},
+	} {
+		c := Crop(f, tc.r)
+		if b := c.Bounds(); b != tc.want && !(b.Empty() && tc.want.Empty()) {
+			t.Errorf("Crop(%v): bounds %v, want %v", tc.r, b, tc.want)
+			continue
+		}
+		g := LuminanceImage(c)
+		for y := -1; y < 5; y++ {
+			for x := -1; x < 9; x++ {
+				in := image.Pt(x, y).In(tc.want)
+				want := color.Color(color.RGBA{})
+				if in {
+					want = f.At(x, y)
+				}
+				if got := c.At(x, y); got != want {
+					t.Errorf("Crop(%v): At(%d, %d): got %v, want %v", tc.r, x, y, got, want)
+				}
+				if in && g.GrayAt(x, y).Y != uint8(y*8+x) {
+					t.Errorf("Crop(%v): luminance at (%d, %d): got %d", tc.r, x, y, g.GrayAt(x, y).Y)
+				}
+			}
+		}
+	}
+	// Releasing a view releases the source.
+	Crop(f, image.Rect(0, 0, 2, 2)).Release()
+	if released != 1 {
+		t.Fatalf("release called %d times", released)
+	}
+}
+
+func TestCropCenter(t *testing.T) {
+	f := rampFrame(t, nil)
+	for _, tc := range []struct {
+		w, h int
+		want image.Rectangle
+	}{
+		{4, 2, image.Rect(2, 1, 6, 3)},
+		{3, 3, image.Rect(2, 0, 5, 3)},
+		{20, 20, image.Rect(0, 0, 8, 4)},
+		{0, 0, image.Rectangle{}},
+	} {
+		if b := CropCenter(f, tc.w, tc.h).Bounds(); b != tc.want && !(b.Empty() && tc.want.Empty()) {
+			t.Errorf("CropCenter(%d, %d): got %v, want %v", tc.w, tc.h, b, tc.want)
+		}
+	}
+}
+
+func TestCropToAspect(t *testing.T) {
+	f := rampFrame(t, nil)
+	for _, tc := range []struct {
+		num, den int
+		want     image.Rectangle
+	}{
+		{1, 1, image.Rect(2, 0, 6, 4)},
+		{4, 1, image.Rect(0, 1, 8, 3)},
+		{2, 1, image.Rect(0, 0, 8, 4)},
+		{16, 9, image.Rect(0, 0, 7, 4)},
+		{0, 1, image.Rect(0, 0, 8, 4)},
+		{1, -1, image.Rect(0, 0, 8, 4)},
+	} {
+		if b := CropToAspect(f, tc.num, tc.den).Bounds(); b != tc.want {
+			t.Errorf("CropToAspect(%d, %d): got %v, want %v", tc.num, tc.den, b, tc.want)
+		}
+	}
+}
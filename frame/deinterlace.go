@@ -0,0 +1,28 @@
+package frame
+
+import (
+	"image"
+)
+
+// Deinterlace converts an interlaced frame to a progressive image,
+// using the top field (the even lines) and interpolating the lines
+// of the bottom field (bob deinterlacing). This removes the combing
+// seen on moving objects, at the cost of half the vertical resolution.
+func Deinterlace(f Frame) *image.RGBA {
+	dst := toRGBA(f)
+	b := dst.Bounds()
+	row := b.Dx() * 4
+	for y := b.Min.Y + 1; y < b.Max.Y; y += 2 {
+		cur := dst.Pix[dst.PixOffset(b.Min.X, y):][:row]
+		above := dst.Pix[dst.PixOffset(b.Min.X, y-1):][:row]
+		if y+1 >= b.Max.Y {
+			copy(cur, above)
+			continue
+		}
+		below := dst.Pix[dst.PixOffset(b.Min.X, y+1):][:row]
+		for i := range cur {
+			cur[i] = uint8((uint16(above[i]) + uint16(below[i]) + 1) / 2)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,154 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// TIFF field types.
+const (
+	tiffByte      = 1
+	tiffASCII     = 2
+	tiffShort     = 3
+	tiffLong      = 4
+	tiffRational  = 5
+	tiffSRational = 10
+)
+
+// Size in bytes of each TIFF field type.
+var tiffTypeSize = map[uint16]int{tiffByte: 1, tiffASCII: 1, tiffShort: 2, tiffLong: 4, tiffRational: 8, tiffSRational: 8}
+
+// The XYZ (D65) to linear sRGB matrix, which is used as the colour matrix
+// since the response of the sensor is not known. Values are scaled by 10000.
+var dngColorMatrix = [9]int32{
+	32406, -15372, -4986,
+	-9689, 18758, 415,
+	557, -2040, 10570,
+}
+
+type tiffEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+// EncodeDNG writes an 8 bit Bayer frame as a minimal DNG, so that the
+// undemosaiced data can be processed by a raw converter such as RawTherapee.
+// The raw data is taken from frames of the Bayer framers (which use the
+// stride of the frame), or otherwise from Raw, which must be the width by
+// height bytes of Bayer data in the pattern given.
+// No colour calibration is available, so the sRGB matrix is used
+// and the white balance is left to the raw converter.
+func EncodeDNG(w io.Writer, f Frame, pattern BayerPattern) error {
+	b := f.Bounds()
+	width, height := b.Dx(), b.Dy()
+	data, stride := RawOf(f), width
+	if r, ok := f.(interface{ rawPlane() ([]byte, int) }); ok {
+		data, stride = r.rawPlane()
+	}
+	if width <= 0 || height <= 0 || len(data) < stride*(height-1)+width {
+		return fmt.Errorf("raw data (%d bytes) too short for %dx%d Bayer frame", len(data), width, height)
+	}
+	le := binary.LittleEndian
+	short := func(v ...uint16) []byte {
+		d := make([]byte, 2*len(v))
+		for i, x := range v {
+			le.PutUint16(d[2*i:], x)
+		}
+		return d
+	}
+	long := func(v uint32) []byte {
+		d := make([]byte, 4)
+		le.PutUint32(d, v)
+		return d
+	}
+	cfa := pattern.cfa()
+	matrix := make([]byte, 0, 9*8)
+	for _, v := range dngColorMatrix {
+		matrix = append(matrix, long(uint32(v))...)
+		matrix = append(matrix, long(10000)...)
+	}
+	neutral := bytes.Repeat(append(long(1), long(1)...), 3)
+	model := []byte("webcam\x00")
+	imageSize := uint32(width * height)
+	entries := []tiffEntry{
+		{254, tiffLong, 1, long(0)},
+		{256, tiffLong, 1, long(uint32(width))},
+		{257, tiffLong, 1, long(uint32(height))},
+		{258, tiffShort, 1, short(8)},
+		{259, tiffShort, 1, short(1)},     // No compression.
+		{262, tiffShort, 1, short(32803)}, // Colour filter array.
+		{273, tiffLong, 1, nil},           // Strip offset, set below.
+		{274, tiffShort, 1, short(1)},
+		{277, tiffShort, 1, short(1)},
+		{278, tiffLong, 1, long(uint32(height))},
+		{279, tiffLong, 1, long(imageSize)},
+		{284, tiffShort, 1, short(1)},
+		{33421, tiffShort, 2, short(2, 2)},
+		{33422, tiffByte, 4, cfa[:]},
+		{50706, tiffByte, 4, []byte{1, 4, 0, 0}},
+		{50707, tiffByte, 4, []byte{1, 1, 0, 0}},
+		{50708, tiffASCII, uint32(len(model)), model},
+		{50717, tiffLong, 1, long(255)},
+		{50721, tiffSRational, 9, matrix},
+		{50728, tiffRational, 3, neutral},
+		{50778, tiffShort, 1, short(21)}, // D65.
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].tag < entries[j].tag })
+	// Layout: header, IFD, out of line values, then the image data.
+	const headerSize = 8
+	ifdSize := 2 + 12*len(entries) + 4
+	offset := uint32(headerSize + ifdSize)
+	var extra []byte
+	outOfLine := make([]uint32, len(entries))
+	for i, e := range entries {
+		if e.tag == 273 {
+			continue
+		}
+		if len(e.data) > 4 {
+			outOfLine[i] = offset + uint32(len(extra))
+			extra = append(extra, e.data...)
+			if len(extra)%2 != 0 {
+				extra = append(extra, 0) // Values start on a word boundary.
+			}
+		}
+	}
+	dataOffset := offset + uint32(len(extra))
+	var buf bytes.Buffer
+	buf.WriteString("II")
+	buf.Write(short(42))
+	buf.Write(long(headerSize))
+	buf.Write(short(uint16(len(entries))))
+	for i, e := range entries {
+		if e.tag == 273 {
+			e.data = long(dataOffset)
+		}
+		if n := tiffTypeSize[e.typ] * int(e.count); n != len(e.data) {
+			return fmt.Errorf("DNG tag %d: %d bytes of data, expected %d", e.tag, len(e.data), n)
+		}
+		buf.Write(short(e.tag, e.typ))
+		buf.Write(long(e.count))
+		if len(e.data) > 4 {
+			buf.Write(long(outOfLine[i]))
+		} else {
+			v := make([]byte, 4)
+			copy(v, e.data)
+			buf.Write(v)
+		}
+	}
+	buf.Write(long(0)) // No further IFDs.
+	buf.Write(extra)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	for y := 0; y < height; y++ {
+		if _, err := w.Write(data[y*stride : y*stride+width]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
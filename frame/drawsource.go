@@ -0,0 +1,52 @@
+package frame
+
+import (
+	"image"
+)
+
+// drawSourcer is implemented by frames that can be represented directly
+// as one of the concrete image types that image/draw handles efficiently.
+type drawSourcer interface {
+	drawSource() image.Image
+}
+
+// AsDrawSource returns an image for use as the source of draw.Draw.
+// Where the frame layout permits, a zero-copy view over the frame is
+// returned (e.g *image.NRGBA for AB24 and *image.YCbCr for YU12), which
+// is only valid until the frame is released. Otherwise the frame is
+// copied to an *image.RGBA, since draw.Draw is slow for images that
+// only provide At.
+func AsDrawSource(img image.Image) image.Image {
+	if s, ok := img.(drawSourcer); ok {
+		if v := s.drawSource(); v != nil {
+			return v
+		}
+	}
+	switch img.(type) {
+	case *image.RGBA, *image.NRGBA, *image.YCbCr, *image.Gray, *image.CMYK, *image.Uniform:
+		return img
+	}
+	return toRGBA(img)
+}
+
+// drawSource returns an NRGBA view for formats stored as R, G, B, A.
+func (f *fRGB) drawSource() image.Image {
+	if f.pixel != 4 || f.roffs != 0 || f.goffs != 1 || f.boffs != 2 || f.aoffs != 3 {
+		return nil
+	}
+	return &image.NRGBA{Pix: f.frame, Stride: f.stride, Rect: f.b}
+}
+
+func (f *fYUV420) drawSource() image.Image {
+	return f.img
+}
+
+// drawSource returns the decoded image, which is usually an image.YCbCr.
+func (f *fJPEG) drawSource() image.Image {
+	return AsDrawSource(f.img)
+}
+
+// drawSource returns the decoded image, which is usually an image.YCbCr.
+func (f *fMJPEG) drawSource() image.Image {
+	return AsDrawSource(f.decode())
+}
@@ -0,0 +1,52 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+)
+
+// Length of the PNG signature and IHDR chunk.
+const pngHeaderLen = 8 + 4 + 4 + 13 + 4
+
+// EncodePNG writes the image as a PNG. If dpi is greater than 0, a pHYs
+// chunk is added so that the physical size of the image is recorded,
+// which is used by printing and measurement tools.
+// Images with an alpha channel (e.g AR24 frames) are written with alpha,
+// and opaque images are written without it.
+func EncodePNG(w io.Writer, img image.Image, dpi float64) error {
+	if dpi <= 0 {
+		return png.Encode(w, img)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	b := buf.Bytes()
+	// The pHYs chunk must appear before the image data, so insert it
+	// directly after the IHDR chunk.
+	if _, err := w.Write(b[:pngHeaderLen]); err != nil {
+		return err
+	}
+	if _, err := w.Write(physChunk(dpi)); err != nil {
+		return err
+	}
+	_, err := w.Write(b[pngHeaderLen:])
+	return err
+}
+
+// physChunk returns a pHYs chunk for the resolution in dots per inch.
+func physChunk(dpi float64) []byte {
+	ppm := uint32(dpi/0.0254 + 0.5)
+	c := make([]byte, 4+4+9+4)
+	binary.BigEndian.PutUint32(c[0:], 9)
+	copy(c[4:], "pHYs")
+	binary.BigEndian.PutUint32(c[8:], ppm)
+	binary.BigEndian.PutUint32(c[12:], ppm)
+	c[16] = 1 // Unit is the metre.
+	binary.BigEndian.PutUint32(c[17:], crc32.ChecksumIEEE(c[4:17]))
+	return c
+}
@@ -0,0 +1,67 @@
+package frame
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// ErrNotDecodable is returned by the Err method of frames of video
+// formats (such as H264) that are passed through without decoding.
+var ErrNotDecodable = errors.New("frame cannot be decoded to an image")
+
+// Video formats that are passed through without decoding.
+var encodedFormats = map[FourCC]bool{
+	"H264": true,
+	"VP80": true,
+	"VP90": true,
+}
+
+// fEncoded is a frame of compressed video, such as the NAL units of
+// an H264 frame. It has no image content.
+type fEncoded struct {
+	Base
+}
+
+// Register the passthrough framer for the video formats.
+func init() {
+	for f := range encodedFormats {
+		RegisterFramer(f, newEncodedFramer)
+	}
+}
+
+// Return a framer for compressed video.
+func newEncodedFramer(w, h, stride, size int) func([]byte, func()) (Frame, error) {
+	return encodedFramer
+}
+
+// Wrap a compressed video frame, which is only accessible using Raw.
+func encodedFramer(b []byte, rel func()) (Frame, error) {
+	f := &fEncoded{Base: NewBase(b, rel)}
+	SetReleaseFinalizer(f)
+	return f, nil
+}
+
+// IsEncoded returns true if frames of the format are compressed video,
+// and so can only be accessed as raw data.
+func IsEncoded(format FourCC) bool {
+	return encodedFormats[format]
+}
+
+func (f *fEncoded) ColorModel() color.Model {
+	return color.RGBAModel
+}
+
+// Bounds returns an empty rectangle, since the frame cannot be decoded.
+func (f *fEncoded) Bounds() image.Rectangle {
+	return image.Rectangle{}
+}
+
+func (f *fEncoded) At(x, y int) color.Color {
+	return color.RGBA{}
+}
+
+// Err returns ErrNotDecodable, to show that the image is not valid.
+func (f *fEncoded) Err() error {
+	return ErrNotDecodable
+}
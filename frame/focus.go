@@ -0,0 +1,28 @@
+package frame
+
+// FocusMetric returns a sharpness score for the frame, calculated as
+// the variance of the Laplacian of the luminance. A higher score means
+// a sharper image; the score is only meaningful when comparing frames
+// of the same scene, e.g to reject blurry frames or to drive a focus control.
+func FocusMetric(f Frame) float64 {
+	g := LuminanceImage(f)
+	b := g.Bounds()
+	if b.Dx() < 3 || b.Dy() < 3 {
+		return 0
+	}
+	var sum, sumSq float64
+	var n int
+	for y := b.Min.Y + 1; y < b.Max.Y-1; y++ {
+		for x := b.Min.X + 1; x < b.Max.X-1; x++ {
+			i := g.PixOffset(x, y)
+			l := 4*int(g.Pix[i]) - int(g.Pix[i-1]) - int(g.Pix[i+1]) -
+				int(g.Pix[i-g.Stride]) - int(g.Pix[i+g.Stride])
+			v := float64(l)
+			sum += v
+			sumSq += v * v
+			n++
+		}
+	}
+	mean := sum / float64(n)
+	return sumSq/float64(n) - mean*mean
+}
@@ -0,0 +1,67 @@
+package frame
+
+import (
+	"testing"
+)
+
+// greyFrame returns a GREY frame of the pixels, which are w wide.
+func greyFrame(t *testing.T, pix []byte, w int) Frame {
+	t.Helper()
+	framer, err := GetFramer("GREY", w, len(pix)/w, w, len(pix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := framer(pix, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestFocusMetric(t *testing.T) {
+	const w, h = 16, 16
+	// A checkerboard of 4x4 squares, and a box blurred copy of it.
+	sharp := make([]byte, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if (x/4+y/4)%2 == 0 {
+				sharp[y*w+x] = 220
+			} else {
+				sharp[y*w+x] = 30
+			}
+		}
+	}
+	blurred := make([]byte, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sum, n := 0, 0
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					if sx, sy := x+dx, y+dy; sx >= 0 && sx < w && sy >= 0 && sy < h {
+						sum += int(sharp[sy*w+sx])
+						n++
+					}
+				}
+			}
+			blurred[y*w+x] = uint8(sum / n)
+		}
+	}
+	flat := make([]byte, w*h)
+	for i := range flat {
+		flat[i] = 128
+	}
+	s := FocusMetric(greyFrame(t, sharp, w))
+	b := FocusMetric(greyFrame(t, blurred, w))
+	if s <= b {
+		t.Fatalf("sharp image scored %g, not higher than blurred %g", s, b)
+	}
+	if b <= 0 {
+		t.Fatalf("blurred image scored %g", b)
+	}
+	if m := FocusMetric(greyFrame(t, flat, w)); m != 0 {
+		t.Fatalf("flat image scored %g, want 0", m)
+	}
+	if m := FocusMetric(greyFrame(t, sharp[:4], 2)); m != 0 {
+		t.Fatalf("2x2 image scored %g, want 0", m)
+	}
+}
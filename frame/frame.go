@@ -4,6 +4,8 @@ package frame
 import (
 	"fmt"
 	"image"
+	"image/color"
+	"time"
 
 	"github.com/aamcrae/webcam"
 )
@@ -18,20 +20,246 @@ type Frame interface {
 	Release()
 }
 
+// Metadata is implemented by frames that record how they were captured.
+// The built in framers, and framers that embed Base, implement it.
+// Timestamp returns the time the frame was captured, or the zero time
+// if not known.
+// Sequence returns the frame sequence number set by the driver.
+type Metadata interface {
+	Timestamp() time.Time
+	Sequence() uint32
+}
+
+// RawData is implemented by frames that provide the data read from the
+// camera. The built in framers, and framers that embed Base, implement it.
+// Raw returns the raw frame data as read from the camera, which
+// is only valid until the frame is released.
+// Fingerprint returns a fast (non-cryptographic) hash of the raw frame data,
+// so that identical frames have the same fingerprint.
+// CopyToBuffer copies the frame data to dst, returning the number of bytes
+// copied. Uncompressed formats copy the raw frame, which is the size
+// reported by the driver (stride * height); compressed formats (MJPG, JPEG)
+// copy the decoded image as RGBA, which is width * height * 4 bytes.
+// An error is returned if dst is too small.
+type RawData interface {
+	Raw() []byte
+	Fingerprint() uint64
+	CopyToBuffer(dst []byte) (int, error)
+}
+
+// Decoder is implemented by frames that are decoded the first time the
+// image is accessed (MJPG), and by frames that cannot be decoded at all
+// (e.g H264). If decoding fails the frame
+// is an empty image, so Err should be checked before the image is used.
+// Err decodes the frame if it has not yet been decoded, and returns any
+// error from decoding it. A frame that is released before it is decoded
+// is never decoded, and Err returns ErrNotDecodable.
+type Decoder interface {
+	Err() error
+}
+
+// DecodeErr returns the error from decoding the frame, or nil if the
+// frame does not implement Decoder.
+func DecodeErr(f Frame) error {
+	if d, ok := f.(Decoder); ok {
+		return d.Err()
+	}
+	return nil
+}
+
+// TimestampOf returns the time the frame was captured, or the zero time
+// if the frame does not implement Metadata.
+func TimestampOf(f Frame) time.Time {
+	if m, ok := f.(Metadata); ok {
+		return m.Timestamp()
+	}
+	return time.Time{}
+}
+
+// SequenceOf returns the sequence number of the frame, or 0
+// if the frame does not implement Metadata.
+func SequenceOf(f Frame) uint32 {
+	if m, ok := f.(Metadata); ok {
+		return m.Sequence()
+	}
+	return 0
+}
+
+// RawOf returns the raw frame data, or nil if the frame does not
+// implement RawData.
+func RawOf(f Frame) []byte {
+	if r, ok := f.(RawData); ok {
+		return r.Raw()
+	}
+	return nil
+}
+
+// FingerprintOf returns the fingerprint of the raw frame data, or 0
+// if the frame does not implement RawData.
+func FingerprintOf(f Frame) uint64 {
+	if r, ok := f.(RawData); ok {
+		return r.Fingerprint()
+	}
+	return 0
+}
+
+// CopyToBuffer copies the frame data to dst as the frame's CopyToBuffer
+// method does. Frames that do not implement RawData are copied as RGBA.
+func CopyToBuffer(f Frame, dst []byte) (int, error) {
+	if r, ok := f.(RawData); ok {
+		return r.CopyToBuffer(dst)
+	}
+	return copyRGBA(f, dst)
+}
+
 var framerFactoryMap = map[FourCC]func(int, int, int, int) func([]byte, func()) (Frame, error){}
 
 // RegisterFramer registers a framer factory for a format.
 // Note that only one handler can be registered for any single format.
+// See CheckFrame and Base for help in writing framers.
 func RegisterFramer(format FourCC, factory func(int, int, int, int) func([]byte, func()) (Frame, error)) {
 	framerFactoryMap[format] = factory
 }
 
+// HasFramer returns true if a framer is registered for the format.
+func HasFramer(format FourCC) bool {
+	_, ok := framerFactoryMap[format]
+	return ok
+}
+
+// Option modifies a newly created frame. Options are ignored by
+// framers that do not support them.
+type Option func(Frame)
+
 // GetFramer returns a function that wraps the frame for this format.
-func GetFramer(format FourCC, w, h, stride, size int) (func([]byte, func()) (Frame, error), error) {
-	if factory, ok := framerFactoryMap[format]; ok {
-		return factory(w, h, stride, size), nil
+// Any options are applied to each frame as it is created.
+func GetFramer(format FourCC, w, h, stride, size int, opts ...Option) (func([]byte, func()) (Frame, error), error) {
+	factory, ok := framerFactoryMap[format]
+	if !ok {
+		return nil, fmt.Errorf("No handler for format '%s'", format)
+	}
+	framer := factory(w, h, stride, size)
+	if len(opts) == 0 {
+		return framer, nil
+	}
+	return func(b []byte, rel func()) (Frame, error) {
+		f, err := framer(b, rel)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range opts {
+			o(f)
+		}
+		return f, nil
+	}, nil
+}
+
+// WithTimestamp sets the capture time of the frame.
+func WithTimestamp(t time.Time) Option {
+	return func(f Frame) {
+		if s, ok := f.(interface{ setTimestamp(time.Time) }); ok {
+			s.setTimestamp(t)
+		}
+	}
+}
+
+// WithSequence sets the sequence number of the frame.
+func WithSequence(seq uint32) Option {
+	return func(f Frame) {
+		if s, ok := f.(interface{ setSequence(uint32) }); ok {
+			s.setSequence(seq)
+		}
+	}
+}
+
+// WithTag attaches a caller supplied value to the frame, such as the
+// request that the frame was captured for, so that frames delivered
+// asynchronously can be matched to the originating work. The value is
+// retrieved with TagOf.
+func WithTag(v interface{}) Option {
+	return func(f Frame) {
+		if s, ok := f.(interface{ setTag(interface{}) }); ok {
+			s.setTag(v)
+		}
+	}
+}
+
+// TagOf returns the value attached to the frame with WithTag,
+// or nil if there is none.
+func TagOf(f Frame) interface{} {
+	if t, ok := f.(interface{ Tag() interface{} }); ok {
+		return t.Tag()
+	}
+	return nil
+}
+
+// WithFramer records the framer that created the frame, so that a copy
+// of the raw frame can be decoded in the same way. It is retrieved with
+// FramerOf.
+func WithFramer(fn func([]byte, func()) (Frame, error)) Option {
+	return func(f Frame) {
+		if s, ok := f.(interface {
+			setFramer(func([]byte, func()) (Frame, error))
+		}); ok {
+			s.setFramer(fn)
+		}
+	}
+}
+
+// FramerOf returns the framer recorded with WithFramer,
+// or nil if there is none.
+func FramerOf(f Frame) func([]byte, func()) (Frame, error) {
+	if t, ok := f.(interface {
+		Framer() func([]byte, func()) (Frame, error)
+	}); ok {
+		return t.Framer()
+	}
+	return nil
+}
+
+// ColorModel selects the color model returned by frames that support it.
+// The RGB framers support color.RGBAModel (the default) and color.NRGBAModel.
+func ColorModel(m color.Model) Option {
+	return func(f Frame) {
+		if s, ok := f.(interface{ setColorModel(color.Model) }); ok {
+			s.setColorModel(m)
+		}
+	}
+}
+
+// SwapRB swaps the red and blue channels of RGB frames, regardless of the
+// nominal format. This corrects drivers that mislabel BGR data as RGB
+// (or vice versa), which produces blue tinted images.
+func SwapRB() Option {
+	return func(f Frame) {
+		if s, ok := f.(interface{ swapRB() }); ok {
+			s.swapRB()
+		}
+	}
+}
+
+// ExpandGray causes grayscale (GREY) frames to present themselves as RGBA
+// images with R, G and B set to the luminance, for consumers that only
+// accept RGB images.
+func ExpandGray() Option {
+	return func(f Frame) {
+		if s, ok := f.(interface{ setExpandGray(bool) }); ok {
+			s.setExpandGray(true)
+		}
+	}
+}
+
+// LimitedRange causes YUV frames (YUYV, YU12 and YV12) to be decoded as
+// limited range (luma 16-235, chroma 16-240), as used by most video
+// sources, rather than full range as used by JPEG. The frame data is
+// expanded to full range when the frame is created, and Raw continues
+// to return the data read from the camera.
+func LimitedRange() Option {
+	return func(f Frame) {
+		if s, ok := f.(interface{ setLimitedRange() }); ok {
+			s.setLimitedRange()
+		}
 	}
-	return nil, fmt.Errorf("No handler for format '%s'", format)
 }
 
 // PixelFormatToFourCC converts the v4l2 PixelFormat to a FourCC.
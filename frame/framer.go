@@ -0,0 +1,126 @@
+package frame
+
+import (
+	"fmt"
+	"hash/fnv"
+	"runtime"
+	"time"
+)
+
+// The following are provided to help with writing framers.
+// A typical framer checks the frame with CheckFrame, embeds Base
+// in the frame type, and calls SetReleaseFinalizer on the new frame, e.g:
+//
+//	func myFramer(b []byte, rel func()) (frame.Frame, error) {
+//		if err := frame.CheckFrame(b, size, rel); err != nil {
+//			return nil, err
+//		}
+//		f := &myFrame{Base: frame.NewBase(b, rel), frame: b}
+//		frame.SetReleaseFinalizer(f)
+//		return f, nil
+//	}
+
+// Base holds the state common to all frames, and implements
+// the Release method of Frame, and the Metadata and RawData interfaces.
+type Base struct {
+	raw       []byte
+	release   func()
+	timestamp time.Time
+	sequence  uint32
+	tag       interface{}
+	framer    func([]byte, func()) (Frame, error)
+}
+
+// NewBase returns a Base for the raw frame b, that calls rel
+// when the frame is released.
+func NewBase(b []byte, rel func()) Base {
+	return Base{raw: b, release: rel}
+}
+
+// Done with frame, release back to camera (if required).
+func (b *Base) Release() {
+	if b.release != nil {
+		b.release()
+		// Make sure it only gets called once.
+		b.release = nil
+	}
+}
+
+func (b *Base) Timestamp() time.Time {
+	return b.timestamp
+}
+
+// Fingerprint returns a 64 bit FNV-1a hash of the raw frame.
+// As with the image data, it is only valid until the frame is released.
+func (b *Base) Fingerprint() uint64 {
+	h := fnv.New64a()
+	h.Write(b.raw)
+	return h.Sum64()
+}
+
+// CopyToBuffer copies the raw frame to dst, which must be at least
+// as large as the raw frame (the size reported by SetImageFormat).
+// It returns the number of bytes copied.
+func (b *Base) CopyToBuffer(dst []byte) (int, error) {
+	if len(dst) < len(b.raw) {
+		return 0, fmt.Errorf("buffer too small (need %d, have %d)", len(b.raw), len(dst))
+	}
+	return copy(dst, b.raw), nil
+}
+
+// Raw returns the raw frame data, which is only valid until
+// the frame is released.
+func (b *Base) Raw() []byte {
+	return b.raw
+}
+
+func (b *Base) setTimestamp(t time.Time) {
+	b.timestamp = t
+}
+
+func (b *Base) Sequence() uint32 {
+	return b.sequence
+}
+
+func (b *Base) setSequence(s uint32) {
+	b.sequence = s
+}
+
+// Tag returns the value set by WithTag, or nil.
+func (b *Base) Tag() interface{} {
+	return b.tag
+}
+
+func (b *Base) setTag(v interface{}) {
+	b.tag = v
+}
+
+// Framer returns the framer set by WithFramer, or nil.
+func (b *Base) Framer() func([]byte, func()) (Frame, error) {
+	return b.framer
+}
+
+func (b *Base) setFramer(fn func([]byte, func()) (Frame, error)) {
+	b.framer = fn
+}
+
+// CheckFrame checks that the frame is the expected length.
+// If not, the frame is released (as framers must do on error),
+// and an error is returned.
+func CheckFrame(b []byte, expected int, rel func()) error {
+	if len(b) != expected {
+		if rel != nil {
+			rel()
+		}
+		return fmt.Errorf("Wrong frame length (exp: %d, read %d)", expected, len(b))
+	}
+	return nil
+}
+
+// SetReleaseFinalizer sets a finalizer on the frame that releases it,
+// as a precaution in case Release is not called.
+func SetReleaseFinalizer(f Frame) {
+	runtime.SetFinalizer(f, func(obj Frame) {
+		obj.Release()
+	})
+}
@@ -0,0 +1,158 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// rawSample is the header of a raw frame in testdata, in the format
+// written by snapshot.DumpRaw.
+type rawSample struct {
+	Magic     [4]byte
+	Format    [4]byte
+	Width     uint32
+	Height    uint32
+	Stride    uint32
+	Sequence  uint32
+	Timestamp int64
+	Size      uint32
+}
+
+// Expected results of decoding the sample for each registered format.
+// A framer that is registered must add a sample to testdata and an
+// entry here. The checksum is the CRC-32 of the RGBA pixels of the
+// decoded frame, as read using At. The MJPG sample is the JPEG sample
+// without its Huffman tables, as sent by cameras, so both decode to the
+// same image.
+var framerSamples = map[FourCC]struct {
+	bounds image.Rectangle
+	crc    uint32
+}{
+	"RGB3": {image.Rect(0, 0, 8, 4), 0x0f4f370f},
+	"BGR3": {image.Rect(0, 0, 8, 4), 0x4c2cdf08},
+	"XR24": {image.Rect(0, 0, 8, 4), 0x449fecad},
+	"BX24": {image.Rect(0, 0, 8, 4), 0x90604974},
+	"AR24": {image.Rect(0, 0, 8, 4), 0xf69de25f},
+	"AB24": {image.Rect(0, 0, 8, 4), 0xbab7497b},
+	"BA24": {image.Rect(0, 0, 8, 4), 0x210c2280},
+	"RGGB": {image.Rect(0, 0, 8, 4), 0x0910e6a1},
+	"BA81": {image.Rect(0, 0, 8, 4), 0x0961d898},
+	"GRBG": {image.Rect(0, 0, 8, 4), 0x03caad07},
+	"GBRG": {image.Rect(0, 0, 8, 4), 0xca51afc2},
+	"GREY": {image.Rect(0, 0, 8, 4), 0x70310023},
+	"YUYV": {image.Rect(0, 0, 8, 4), 0x6f5dd58b},
+	"YU12": {image.Rect(0, 0, 8, 4), 0xa3ea8c6f},
+	"YV12": {image.Rect(0, 0, 8, 4), 0xc2618bb4},
+	"JPEG": {image.Rect(0, 0, 16, 8), 0x2ac8f006},
+	"MJPG": {image.Rect(0, 0, 16, 8), 0x2ac8f006},
+	"H264": {image.Rectangle{}, 0},
+	"VP80": {image.Rectangle{}, 0},
+	"VP90": {image.Rectangle{}, 0},
+}
+
+// readSample reads the raw frame recorded for the format.
+func readSample(t *testing.T, format FourCC) (rawSample, []byte) {
+	b, err := os.ReadFile(filepath.Join("testdata", string(format)+".raw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var h rawSample
+	r := bytes.NewReader(b)
+	if err := binary.Read(r, binary.BigEndian, &h); err != nil {
+		t.Fatal(err)
+	}
+	if string(h.Magic[:]) != "WRAW" || FourCC(h.Format[:]) != format {
+		t.Fatalf("%s: bad sample header", format)
+	}
+	data := b[len(b)-r.Len():]
+	if len(data) != int(h.Size) {
+		t.Fatalf("%s: sample has %d bytes, header says %d", format, len(data), h.Size)
+	}
+	return h, data
+}
+
+// rgbaChecksum returns the CRC-32 of the RGBA pixels of the image.
+func rgbaChecksum(img image.Image) uint32 {
+	b := img.Bounds()
+	pix := make([]byte, 0, b.Dx()*b.Dy()*4)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			pix = append(pix, c.R, c.G, c.B, c.A)
+		}
+	}
+	return crc32.ChecksumIEEE(pix)
+}
+
+func TestFramers(t *testing.T) {
+	for format := range framerFactoryMap {
+		format := format
+		t.Run(string(format), func(t *testing.T) {
+			want, ok := framerSamples[format]
+			if !ok {
+				t.Fatalf("no sample for registered format %s", format)
+			}
+			h, data := readSample(t, format)
+			framer, err := GetFramer(format, int(h.Width), int(h.Height), int(h.Stride), len(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			released := 0
+			f, err := framer(data, func() { released++ })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b := f.Bounds(); b != want.bounds {
+				t.Errorf("Bounds: got %v, want %v", b, want.bounds)
+			}
+			if crc := rgbaChecksum(f); crc != want.crc {
+				t.Errorf("checksum: got %#08x, want %#08x", crc, want.crc)
+			}
+			if raw := RawOf(f); !bytes.Equal(raw, data) {
+				t.Errorf("Raw: got %d bytes, want the %d bytes of the sample", len(raw), len(data))
+			}
+			f.Release()
+			if released != 1 {
+				t.Errorf("release called %d times", released)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	h, data := readSample(t, "YUYV")
+	framer, err := GetFramer("YUYV", int(h.Width), int(h.Height), int(h.Stride), len(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decode := func(b []byte) Frame {
+		f, err := framer(b, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	a := decode(append([]byte(nil), data...))
+	b := decode(append([]byte(nil), data...))
+	if FingerprintOf(a) != FingerprintOf(b) {
+		t.Fatalf("identical frames have fingerprints %#x and %#x", FingerprintOf(a), FingerprintOf(b))
+	}
+	// A fingerprint is of the data, not the frame, so orienting the
+	// frame does not change it.
+	if FingerprintOf(Orient(a, OrientRotate90)) != FingerprintOf(a) {
+		t.Fatal("oriented frame has a different fingerprint")
+	}
+	for _, i := range []int{0, len(data) / 2, len(data) - 1} {
+		changed := append([]byte(nil), data...)
+		changed[i]++
+		if c := decode(changed); FingerprintOf(c) == FingerprintOf(a) {
+			t.Errorf("changing byte %d did not change the fingerprint", i)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+)
+
+// Spread of the well-exposedness weight around mid-grey, as in Mertens et al.
+const fusionSigma = 0.2
+
+// FuseExposures merges images of the same static scene taken with different
+// exposures into a single tone-mapped image, using a simplified (single
+// scale) form of Mertens exposure fusion. Each pixel is a weighted average
+// of the input pixels, favouring pixels that are well exposed (close to
+// mid-grey) and saturated, so that shadow detail comes from the longer
+// exposures and highlight detail from the shorter ones.
+// The images must all have the same bounds.
+func FuseExposures(imgs []image.Image) (*image.RGBA, error) {
+	if len(imgs) == 0 {
+		return nil, errors.New("no images to fuse")
+	}
+	b := imgs[0].Bounds()
+	srcs := make([]*image.RGBA, len(imgs))
+	for i, img := range imgs {
+		if img.Bounds() != b {
+			return nil, fmt.Errorf("image %d bounds %v differ from %v", i, img.Bounds(), b)
+		}
+		srcs[i] = toRGBA(img)
+	}
+	// Well-exposedness of each component value.
+	var exposed [256]float32
+	for v := range exposed {
+		d := float64(v)/255 - 0.5
+		exposed[v] = float32(math.Exp(-d * d / (2 * fusionSigma * fusionSigma)))
+	}
+	dst := image.NewRGBA(b)
+	for i := 0; i < len(dst.Pix); i += 4 {
+		var sum, r, g, bl float32
+		for _, src := range srcs {
+			p := src.Pix[i : i+3 : i+3]
+			w := exposed[p[0]]*exposed[p[1]]*exposed[p[2]]*(saturation(p[0], p[1], p[2])+0.01) + 1e-6
+			sum += w
+			r += w * float32(p[0])
+			g += w * float32(p[1])
+			bl += w * float32(p[2])
+		}
+		dst.Pix[i] = uint8(r/sum + 0.5)
+		dst.Pix[i+1] = uint8(g/sum + 0.5)
+		dst.Pix[i+2] = uint8(bl/sum + 0.5)
+		dst.Pix[i+3] = 0xFF
+	}
+	return dst, nil
+}
+
+// saturation returns the standard deviation of the components, scaled to 0-1.
+func saturation(r, g, b uint8) float32 {
+	fr, fg, fb := float32(r)/255, float32(g)/255, float32(b)/255
+	mean := (fr + fg + fb) / 3
+	v := ((fr-mean)*(fr-mean) + (fg-mean)*(fg-mean) + (fb-mean)*(fb-mean)) / 3
+	return float32(math.Sqrt(float64(v)))
+}
@@ -0,0 +1,94 @@
+package frame
+
+import (
+	"image"
+	"image/color"
+)
+
+type fGrey struct {
+	expand bool // Present the frame as RGBA rather than Gray.
+	b      image.Rectangle
+	stride int
+	frame  []byte
+	Base
+}
+
+// Register a framer factory for this format.
+func init() {
+	RegisterFramer("GREY", newFramerGrey)
+}
+
+func newFramerGrey(w, h, stride, size int) func([]byte, func()) (Frame, error) {
+	if err := checkLayout("GREY", w, h, stride, size); err != nil {
+		return failFramer(err)
+	}
+	return func(b []byte, rel func()) (Frame, error) {
+		return frameGrey(size, stride, w, h, b, rel)
+	}
+}
+
+// Wrap a raw webcam frame in a Frame so that it can be used as an image.
+func frameGrey(size, stride, w, h int, b []byte, rel func()) (Frame, error) {
+	if err := CheckFrame(b, size, rel); err != nil {
+		return nil, err
+	}
+	f := &fGrey{b: image.Rect(0, 0, w, h), stride: stride, frame: b, Base: NewBase(b, rel)}
+	SetReleaseFinalizer(f)
+	return f, nil
+}
+
+func (f *fGrey) ColorModel() color.Model {
+	if f.expand {
+		return color.RGBAModel
+	}
+	return color.GrayModel
+}
+
+func (f *fGrey) Bounds() image.Rectangle {
+	return f.b
+}
+
+func (f *fGrey) At(x, y int) color.Color {
+	v := f.frame[f.stride*y+x]
+	if f.expand {
+		return color.RGBA{v, v, v, 0xFF}
+	}
+	return color.Gray{v}
+}
+
+func (f *fGrey) setExpandGray(expand bool) {
+	f.expand = expand
+}
+
+// WriteRGBA copies the frame to dst, with R, G and B set to the luminance.
+func (f *fGrey) WriteRGBA(dst *image.RGBA) error {
+	if err := checkDst(dst, f.b); err != nil {
+		return err
+	}
+	for y := 0; y < f.b.Max.Y; y++ {
+		src := f.frame[f.stride*y : f.stride*y+f.b.Max.X]
+		d := dst.Pix[dst.PixOffset(0, y):]
+		for _, v := range src {
+			d[0], d[1], d[2], d[3] = v, v, v, 0xFF
+			d = d[4:]
+		}
+	}
+	return nil
+}
+
+// drawSource returns a Gray view of the frame.
+func (f *fGrey) drawSource() image.Image {
+	return &image.Gray{Pix: f.frame, Stride: f.stride, Rect: f.b}
+}
+
+// LuminanceAt returns the value of the pixel.
+func (f *fGrey) LuminanceAt(x, y int) uint8 {
+	return f.frame[f.stride*y+x]
+}
+
+// LuminanceImage returns a copy of the frame.
+func (f *fGrey) LuminanceImage() *image.Gray {
+	g := image.NewGray(f.b)
+	copyPlane(g.Pix, g.Stride, f.frame, f.stride, f.b.Dx(), f.b.Dy())
+	return g
+}
@@ -5,12 +5,11 @@ import (
 	"image"
 	"image/color"
 	"image/jpeg"
-	"runtime"
 )
 
 type fJPEG struct {
-	img     image.Image
-	release func()
+	img image.Image
+	Base
 }
 
 // Register this framer for this format.
@@ -32,10 +31,8 @@ func jpegFramer(f []byte, rel func()) (Frame, error) {
 		}
 		return nil, err
 	}
-	fr := &fJPEG{img: img, release: rel}
-	runtime.SetFinalizer(fr, func(obj Frame) {
-		obj.Release()
-	})
+	fr := &fJPEG{img: img, Base: NewBase(f, rel)}
+	SetReleaseFinalizer(fr)
 	return fr, nil
 }
 
@@ -51,11 +48,7 @@ func (f *fJPEG) At(x, y int) color.Color {
 	return f.img.At(x, y)
 }
 
-// Done with frame, release back to camera (if required).
-func (f *fJPEG) Release() {
-	if f.release != nil {
-		f.release()
-		// Make sure it only gets called once.
-		f.release = nil
-	}
+// CopyToBuffer copies the image to dst as RGBA.
+func (f *fJPEG) CopyToBuffer(dst []byte) (int, error) {
+	return copyRGBA(f, dst)
 }
@@ -0,0 +1,74 @@
+package frame
+
+import (
+	"image"
+	"image/color"
+)
+
+// Luminancer is implemented by frames that can return their luminance
+// directly from the frame data, without converting each pixel to RGBA.
+// For YUV formats this is the Y value, and for RGB formats the BT.601
+// weighted sum of the components.
+type Luminancer interface {
+	LuminanceAt(x, y int) uint8
+	LuminanceImage() *image.Gray
+}
+
+// LuminanceAt returns the luminance of the pixel, using the fast path
+// of the frame if it has one.
+func LuminanceAt(img image.Image, x, y int) uint8 {
+	if l, ok := img.(Luminancer); ok {
+		return l.LuminanceAt(x, y)
+	}
+	return lumaOf(img.At(x, y))
+}
+
+// LuminanceImage returns a copy of the luminance of the image, using the
+// fast path of the frame if it has one.
+func LuminanceImage(img image.Image) *image.Gray {
+	switch l := img.(type) {
+	case Luminancer:
+		return l.LuminanceImage()
+	case *image.YCbCr:
+		return yPlane(l)
+	case interface{ YCbCr() *image.YCbCr }:
+		return yPlane(l.YCbCr())
+	}
+	return lumaSlow(img)
+}
+
+// lumaSlow returns the luminance of the image by converting each pixel.
+func lumaSlow(img image.Image) *image.Gray {
+	b := img.Bounds()
+	g := image.NewGray(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			g.Pix[g.PixOffset(x, y)] = lumaOf(img.At(x, y))
+		}
+	}
+	return g
+}
+
+func lumaOf(c color.Color) uint8 {
+	switch c := c.(type) {
+	case color.YCbCr:
+		return c.Y
+	case color.Gray:
+		return c.Y
+	}
+	return color.GrayModel.Convert(c).(color.Gray).Y
+}
+
+// rgbLuma returns the luminance of 8 bit RGB components, calculated
+// in the same way as color.GrayModel.
+func rgbLuma(r, g, b uint8) uint8 {
+	return uint8((19595*uint32(r)*0x101 + 38470*uint32(g)*0x101 + 7471*uint32(b)*0x101 + 1<<15) >> 24)
+}
+
+// yPlane returns a copy of the luma plane.
+func yPlane(img *image.YCbCr) *image.Gray {
+	r := img.Rect
+	g := image.NewGray(r)
+	copyPlane(g.Pix, g.Stride, img.Y[img.YOffset(r.Min.X, r.Min.Y):], img.YStride, r.Dx(), r.Dy())
+	return g
+}
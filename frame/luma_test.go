@@ -0,0 +1,99 @@
+package frame
+
+import (
+	"image"
+	"testing"
+)
+
+// slowFrame hides the fast paths of a frame, so that analysis
+// falls back to converting each pixel.
+type slowFrame struct {
+	Frame
+}
+
+// lumaFrame returns a 640x480 frame of the format filled with a pattern.
+func lumaFrame(tb testing.TB, format FourCC) Frame {
+	w, h := 640, 480
+	stride, size := w, w*h
+	switch format {
+	case "YUYV":
+		stride, size = w*2, w*h*2
+	case "RGB3":
+		stride, size = w*3, w*h*3
+	case "YU12":
+		size = w * h * 3 / 2
+	}
+	b := make([]byte, size)
+	for i := range b {
+		b[i] = uint8(i*7 + i/w)
+	}
+	framer, err := GetFramer(format, w, h, stride, size)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	f, err := framer(b, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return f
+}
+
+var lumaFormats = []FourCC{"GREY", "YUYV", "YU12", "RGB3"}
+
+func TestLuminanceFastPath(t *testing.T) {
+	for _, format := range lumaFormats {
+		f := lumaFrame(t, format)
+		views := map[string]Frame{
+			"frame":  f,
+			"crop":   Crop(f, image.Rect(10, 20, 300, 200)),
+			"orient": Orient(f, OrientRotate90),
+		}
+		for name, v := range views {
+			if _, ok := v.(Luminancer); !ok {
+				t.Errorf("%s %s: no luminance fast path", format, name)
+				continue
+			}
+			fast, slow := LuminanceImage(v), LuminanceImage(slowFrame{v})
+			if fast.Rect != slow.Rect || string(fast.Pix) != string(slow.Pix) {
+				t.Errorf("%s %s: fast path differs from conversion of each pixel", format, name)
+			}
+			if p := (image.Point{5, 7}); LuminanceAt(v, p.X, p.Y) != slow.GrayAt(p.X, p.Y).Y {
+				t.Errorf("%s %s: LuminanceAt differs from conversion of the pixel", format, name)
+			}
+		}
+	}
+}
+
+func benchmarkLuminance(b *testing.B, format FourCC, slow bool) {
+	var f Frame = lumaFrame(b, format)
+	if slow {
+		f = slowFrame{f}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		LuminanceImage(f)
+	}
+}
+
+func BenchmarkLuminanceGREY(b *testing.B)     { benchmarkLuminance(b, "GREY", false) }
+func BenchmarkLuminanceGREYSlow(b *testing.B) { benchmarkLuminance(b, "GREY", true) }
+func BenchmarkLuminanceYUYV(b *testing.B)     { benchmarkLuminance(b, "YUYV", false) }
+func BenchmarkLuminanceYUYVSlow(b *testing.B) { benchmarkLuminance(b, "YUYV", true) }
+func BenchmarkLuminanceYU12(b *testing.B)     { benchmarkLuminance(b, "YU12", false) }
+func BenchmarkLuminanceYU12Slow(b *testing.B) { benchmarkLuminance(b, "YU12", true) }
+func BenchmarkLuminanceRGB3(b *testing.B)     { benchmarkLuminance(b, "RGB3", false) }
+func BenchmarkLuminanceRGB3Slow(b *testing.B) { benchmarkLuminance(b, "RGB3", true) }
+
+func benchmarkFocus(b *testing.B, slow bool) {
+	var f Frame = lumaFrame(b, "YUYV")
+	if slow {
+		f = slowFrame{f}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FocusMetric(f)
+	}
+}
+
+func BenchmarkFocusYUYV(b *testing.B)     { benchmarkFocus(b, false) }
+func BenchmarkFocusYUYVSlow(b *testing.B) { benchmarkFocus(b, true) }
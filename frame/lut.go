@@ -0,0 +1,172 @@
+package frame
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// LUT3D is a color lookup table, as loaded from a .cube file.
+// A 3D table has Size^3 entries, with red varying fastest.
+// A 1D table (three separate channel curves) has Size entries.
+// Entries are RGB triples, normally in the range 0-1.
+type LUT3D struct {
+	Size  int
+	OneD  bool
+	Table [][3]float32
+	Min   [3]float32 // Input domain minimum (default 0)
+	Max   [3]float32 // Input domain maximum (default 1)
+}
+
+// LoadCube reads a LUT in the Adobe/Resolve .cube format.
+func LoadCube(r io.Reader) (*LUT3D, error) {
+	lut := &LUT3D{Max: [3]float32{1, 1, 1}}
+	sc := bufio.NewScanner(r)
+	line := 0
+	for sc.Scan() {
+		line++
+		f := strings.Fields(sc.Text())
+		if len(f) == 0 || strings.HasPrefix(f[0], "#") {
+			continue
+		}
+		switch f[0] {
+		case "TITLE":
+		case "LUT_3D_SIZE", "LUT_1D_SIZE":
+			if len(f) != 2 {
+				return nil, fmt.Errorf("line %d: bad %s", line, f[0])
+			}
+			n, err := strconv.Atoi(f[1])
+			if err != nil || n < 2 {
+				return nil, fmt.Errorf("line %d: bad %s", line, f[0])
+			}
+			lut.Size = n
+			lut.OneD = f[0] == "LUT_1D_SIZE"
+		case "DOMAIN_MIN", "DOMAIN_MAX":
+			v, err := parseTriple(f[1:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			if f[0] == "DOMAIN_MIN" {
+				lut.Min = v
+			} else {
+				lut.Max = v
+			}
+		default:
+			v, err := parseTriple(f)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			lut.Table = append(lut.Table, v)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if lut.Size == 0 {
+		return nil, fmt.Errorf("no LUT size")
+	}
+	exp := lut.Size
+	if !lut.OneD {
+		exp = lut.Size * lut.Size * lut.Size
+	}
+	if len(lut.Table) != exp {
+		return nil, fmt.Errorf("LUT has %d entries, expected %d", len(lut.Table), exp)
+	}
+	return lut, nil
+}
+
+func parseTriple(f []string) ([3]float32, error) {
+	var v [3]float32
+	if len(f) != 3 {
+		return v, fmt.Errorf("expected 3 values")
+	}
+	for i, s := range f {
+		n, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return v, err
+		}
+		v[i] = float32(n)
+	}
+	return v, nil
+}
+
+// ApplyLUT returns a copy of the frame with the LUT applied.
+func ApplyLUT(f Frame, lut *LUT3D) *image.RGBA {
+	dst := toRGBA(f)
+	p := dst.Pix
+	for i := 0; i+3 < len(p); i += 4 {
+		p[i], p[i+1], p[i+2] = lut.lookup(p[i], p[i+1], p[i+2])
+	}
+	return dst
+}
+
+// lookup maps a color through the LUT, interpolating between entries.
+func (l *LUT3D) lookup(r, g, b uint8) (uint8, uint8, uint8) {
+	in := [3]float32{float32(r) / 255, float32(g) / 255, float32(b) / 255}
+	// Scale to the table index range.
+	var pos [3]float32
+	for i := range in {
+		v := (in[i] - l.Min[i]) / (l.Max[i] - l.Min[i])
+		if v < 0 {
+			v = 0
+		} else if v > 1 {
+			v = 1
+		}
+		pos[i] = v * float32(l.Size-1)
+	}
+	var out [3]float32
+	if l.OneD {
+		for c := range pos {
+			i0, i1, t := split(pos[c], l.Size)
+			out[c] = l.Table[i0][c]*(1-t) + l.Table[i1][c]*t
+		}
+	} else {
+		out = l.trilinear(pos)
+	}
+	return unit8(out[0]), unit8(out[1]), unit8(out[2])
+}
+
+// trilinear interpolates the 3D table at the given position.
+func (l *LUT3D) trilinear(pos [3]float32) [3]float32 {
+	r0, r1, tr := split(pos[0], l.Size)
+	g0, g1, tg := split(pos[1], l.Size)
+	b0, b1, tb := split(pos[2], l.Size)
+	at := func(r, g, b int) [3]float32 {
+		return l.Table[r+l.Size*(g+l.Size*b)]
+	}
+	var out [3]float32
+	for c := 0; c < 3; c++ {
+		c00 := at(r0, g0, b0)[c]*(1-tr) + at(r1, g0, b0)[c]*tr
+		c10 := at(r0, g1, b0)[c]*(1-tr) + at(r1, g1, b0)[c]*tr
+		c01 := at(r0, g0, b1)[c]*(1-tr) + at(r1, g0, b1)[c]*tr
+		c11 := at(r0, g1, b1)[c]*(1-tr) + at(r1, g1, b1)[c]*tr
+		c0 := c00*(1-tg) + c10*tg
+		c1 := c01*(1-tg) + c11*tg
+		out[c] = c0*(1-tb) + c1*tb
+	}
+	return out
+}
+
+// split returns the table indices either side of p, and the fraction between them.
+func split(p float32, size int) (int, int, float32) {
+	i0 := int(p)
+	if i0 >= size-1 {
+		return size - 1, size - 1, 0
+	}
+	return i0, i0 + 1, p - float32(i0)
+}
+
+// unit8 converts a value in the range 0-1 to 0-255.
+func unit8(v float32) uint8 {
+	v = v*255 + 0.5
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
@@ -0,0 +1,93 @@
+package frame
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// cube returns a .cube file of the given size mapping each component
+// value v (0-1) with fn.
+func cube(oneD bool, size int, fn func(float64) float64) string {
+	var sb strings.Builder
+	sb.WriteString("TITLE \"test\"\n# comment\n")
+	n := size
+	if oneD {
+		fmt.Fprintf(&sb, "LUT_1D_SIZE %d\n", size)
+	} else {
+		fmt.Fprintf(&sb, "LUT_3D_SIZE %d\n", size)
+		n = size * size * size
+	}
+	for i := 0; i < n; i++ {
+		r, g, b := i%size, i/size%size, i/(size*size)%size
+		if oneD {
+			g, b = i, i
+		}
+		d := float64(size - 1)
+		fmt.Fprintf(&sb, "%g %g %g\n", fn(float64(r)/d), fn(float64(g)/d), fn(float64(b)/d))
+	}
+	return sb.String()
+}
+
+func TestApplyLUT(t *testing.T) {
+	w, h := 4, 4
+	b := make([]byte, w*h*3)
+	for i := range b {
+		b[i] = uint8(i * 37)
+	}
+	framer, err := GetFramer("RGB3", w, h, w*3, len(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := framer(b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := func(v float64) float64 { return v }
+	invert := func(v float64) float64 { return 1 - v }
+	for _, tc := range []struct {
+		name   string
+		oneD   bool
+		size   int
+		fn     func(float64) float64
+		invert bool
+	}{
+		{"3D identity", false, 2, identity, false},
+		{"3D identity 17", false, 17, identity, false},
+		{"3D inversion", false, 2, invert, true},
+		{"1D identity", true, 5, identity, false},
+		{"1D inversion", true, 5, invert, true},
+	} {
+		lut, err := LoadCube(strings.NewReader(cube(tc.oneD, tc.size, tc.fn)))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		img := ApplyLUT(f, lut)
+		for i, v := range b {
+			want := v
+			if tc.invert {
+				want = 255 - v
+			}
+			got := img.Pix[i/3*4+i%3]
+			if absDiff(got, want) > 1 {
+				t.Fatalf("%s: pixel %d component %d: got %d, want %d", tc.name, i/3, i%3, got, want)
+			}
+		}
+	}
+}
+
+func TestLoadCubeErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"0 0 0\n1 1 1\n",
+		"LUT_3D_SIZE 2\n0 0 0\n",
+		"LUT_3D_SIZE 1\n0 0 0\n",
+		"LUT_1D_SIZE x\n",
+		"LUT_1D_SIZE 2\n0 0\n1 1 1\n",
+		"LUT_1D_SIZE 2\nDOMAIN_MIN 0 0\n0 0 0\n1 1 1\n",
+	} {
+		if _, err := LoadCube(strings.NewReader(s)); err == nil {
+			t.Errorf("no error loading %q", s)
+		}
+	}
+}
@@ -6,12 +6,17 @@ import (
 	"image"
 	"image/color"
 	"image/jpeg"
-	"runtime"
+	"sync"
 )
 
+// fMJPEG holds the raw MJPEG block, and decodes it the first time
+// the image is accessed. The decode is only ever done once.
 type fMJPEG struct {
-	img     image.Image
-	release func()
+	once  sync.Once
+	frame []byte
+	img   image.Image
+	err   error
+	Base
 }
 
 const (
@@ -71,6 +76,12 @@ var default_dht []byte = []byte{
 	0xea, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8, 0xf9, 0xfa,
 }
 
+// Error of a frame released before it was decoded.
+var errReleased = fmt.Errorf("%w: released before decoding", ErrNotDecodable)
+
+// Image used when a frame cannot be decoded.
+var emptyMJPEG = image.NewYCbCr(image.Rectangle{}, image.YCbCrSubsampleRatio422)
+
 // Register this framer for this format.
 func init() {
 	RegisterFramer("MJPG", newMJPGFramer)
@@ -81,24 +92,23 @@ func newMJPGFramer(w, h, stride, size int) func([]byte, func()) (Frame, error) {
 }
 
 // Wrap a mjpeg block in a Frame so that it can be used as an image.
-// The standard jpeg decoding does not work if there are no Huffman tables,
-// so check the frame and add a default table if required.
+// The section markers are checked here, but the decoding is deferred
+// until the image is first accessed.
 func mjpegFramer(f []byte, rel func()) (Frame, error) {
-	img, err := decodeMJPEG(f)
-	if err != nil {
+	if err := walkSections(f, nil); err != nil {
 		if rel != nil {
 			rel()
 		}
 		return nil, err
 	}
-	fr := &fMJPEG{img: img, release: rel}
-	runtime.SetFinalizer(fr, func(obj Frame) {
-		obj.Release()
-	})
+	fr := &fMJPEG{frame: f, Base: NewBase(f, rel)}
+	SetReleaseFinalizer(fr)
 	return fr, nil
 }
 
 // decodeMJPEG decodes the frame into an image.
+// The standard jpeg decoding does not work if there are no Huffman tables,
+// so check the frame and add a default table if required.
 func decodeMJPEG(f []byte) (image.Image, error) {
 	sect, err := findConfig(f)
 	if err != nil {
@@ -124,37 +134,76 @@ func decodeMJPEG(f []byte) (image.Image, error) {
 	return jpeg.Decode(buf)
 }
 
+// decode converts the raw frame to an image the first time it is called.
+// If decoding fails, the frame is treated as an empty image.
+func (f *fMJPEG) decode() image.Image {
+	f.once.Do(func() {
+		f.img, f.err = decodeMJPEG(f.frame)
+		if f.err != nil {
+			f.img = emptyMJPEG
+		}
+		f.frame = nil
+	})
+	return f.img
+}
+
 func (f *fMJPEG) ColorModel() color.Model {
-	return f.img.ColorModel()
+	return f.decode().ColorModel()
 }
 
 func (f *fMJPEG) Bounds() image.Rectangle {
-	return f.img.Bounds()
+	return f.decode().Bounds()
 }
 
 func (f *fMJPEG) At(x, y int) color.Color {
-	return f.img.At(x, y)
+	return f.decode().At(x, y)
+}
+
+// Err decodes the frame if required, and returns any error from decoding it.
+func (f *fMJPEG) Err() error {
+	f.decode()
+	return f.err
 }
 
 // Done with frame, release back to camera (if required).
+// The raw buffer is no longer valid once released, so if the frame
+// has not been decoded yet, it is left as an empty image.
 func (f *fMJPEG) Release() {
 	if f.release != nil {
-		f.release()
-		// Make sure it only gets called once.
-		f.release = nil
+		f.once.Do(func() {
+			f.img = emptyMJPEG
+			f.err = errReleased
+			f.frame = nil
+		})
+		f.Base.Release()
 	}
 }
 
 // findConfig returns a map of the different config markers and their location.
 func findConfig(f []byte) (map[byte][]int, error) {
 	m := make(map[byte][]int)
+	err := walkSections(f, func(marker byte, loc int) {
+		m[marker] = append(m[marker], loc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// walkSections checks the section markers of the frame up to the scan
+// data, calling fn (if not nil) with each marker and its location.
+// No map is built, so that frames can be checked without allocating.
+func walkSections(f []byte, fn func(marker byte, loc int)) error {
 	for l := 0; l < len(f)-1; {
 		if f[l] != sectionFlag {
-			return nil, fmt.Errorf("No section marker at location %d", l)
+			return fmt.Errorf("No section marker at location %d", l)
 		}
 		l++
 		marker := f[l]
-		m[marker] = append(m[marker], l-1)
+		if fn != nil {
+			fn(marker, l-1)
+		}
 		l++
 		if marker == soiMarker || marker == eoiMarker {
 			continue
@@ -164,9 +213,14 @@ func findConfig(f []byte) (map[byte][]int, error) {
 		}
 		// next 2 bytes are length of the section (big-endian).
 		if l >= len(f)-2 {
-			return nil, fmt.Errorf("unexpected EOF at location %d", l)
+			return fmt.Errorf("unexpected EOF at location %d", l)
 		}
 		l += (int(f[l]) << 8) + int(f[l+1])
 	}
-	return m, nil
+	return nil
+}
+
+// CopyToBuffer copies the image to dst as RGBA.
+func (f *fMJPEG) CopyToBuffer(dst []byte) (int, error) {
+	return copyRGBA(f, dst)
 }
@@ -0,0 +1,106 @@
+package frame
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"sync"
+	"testing"
+)
+
+// testJPEG returns a JPEG of a w by h gradient.
+func testJPEG(t testing.TB, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 255 / w), uint8(y * 255 / h), 128, 0xFF})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestMJPEGDecodesOnce(t *testing.T) {
+	f, err := mjpegFramer(testJPEG(t, 16, 8), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := f.(*fMJPEG)
+	if m.img != nil {
+		t.Fatal("frame decoded before the image was accessed")
+	}
+	if b := f.Bounds(); b != image.Rect(0, 0, 16, 8) {
+		t.Fatalf("Bounds: got %v, want 16x8", b)
+	}
+	img := m.img
+	// The raw frame is discarded once decoded, so a second decode would fail.
+	if m.frame != nil {
+		t.Fatal("raw frame kept after decoding")
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.At(3, 3)
+			f.Bounds()
+			f.ColorModel()
+		}()
+	}
+	wg.Wait()
+	if m.img != img {
+		t.Fatal("frame decoded more than once")
+	}
+	if err := DecodeErr(f); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+}
+
+func TestMJPEGCorrupt(t *testing.T) {
+	b := testJPEG(t, 16, 8)
+	// Truncate the scan data.
+	b = b[:len(b)-len(b)/4]
+	f, err := mjpegFramer(b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := DecodeErr(f); err == nil {
+		t.Fatal("no error for corrupt frame")
+	}
+	if !f.Bounds().Empty() {
+		t.Fatalf("corrupt frame has bounds %v", f.Bounds())
+	}
+}
+
+func TestMJPEGReleasedBeforeDecode(t *testing.T) {
+	released := 0
+	f, err := mjpegFramer(testJPEG(t, 16, 8), func() { released++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Release()
+	if released != 1 {
+		t.Fatalf("released %d times", released)
+	}
+	if err := DecodeErr(f); !errors.Is(err, ErrNotDecodable) {
+		t.Fatalf("Err: got %v, want ErrNotDecodable", err)
+	}
+	if !f.Bounds().Empty() {
+		t.Fatalf("released frame has bounds %v", f.Bounds())
+	}
+}
+
+func TestMJPEGBadMarker(t *testing.T) {
+	released := false
+	if _, err := mjpegFramer([]byte{0, 1, 2, 3}, func() { released = true }); err == nil {
+		t.Fatal("no error for frame without section markers")
+	}
+	if !released {
+		t.Fatal("frame not released on error")
+	}
+}
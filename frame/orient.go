@@ -0,0 +1,169 @@
+package frame
+
+import (
+	"image"
+	"image/color"
+	"time"
+)
+
+// Orientation is an EXIF orientation value, describing the transform
+// that displays an image upright.
+type Orientation int
+
+const (
+	OrientNormal     Orientation = iota + 1 // No transform.
+	OrientFlipH                             // Mirror horizontally.
+	OrientRotate180                         // Rotate 180 degrees.
+	OrientFlipV                             // Mirror vertically.
+	OrientTranspose                         // Mirror about the top-left to bottom-right diagonal.
+	OrientRotate90                          // Rotate 90 degrees clockwise.
+	OrientTransverse                        // Mirror about the top-right to bottom-left diagonal.
+	OrientRotate270                         // Rotate 270 degrees clockwise.
+)
+
+// OrientationOf returns the orientation applied to the frame by Orient,
+// or OrientNormal if the frame is not transformed.
+func OrientationOf(f Frame) Orientation {
+	if o, ok := f.(interface{ Orientation() Orientation }); ok {
+		return o.Orientation()
+	}
+	return OrientNormal
+}
+
+// fOrient is a view of another frame with an orientation transform applied.
+type fOrient struct {
+	src    Frame
+	o      Orientation
+	min    image.Point // Origin of the source frame.
+	sw, sh int         // Size of the source frame.
+	b      image.Rectangle
+}
+
+// Orient returns a view of the frame with the transform for the orientation
+// applied, without copying. The view has its origin at (0, 0).
+// The frame is returned unchanged for OrientNormal or unknown orientations.
+// Releasing the view releases the source frame.
+func Orient(f Frame, o Orientation) Frame {
+	if o <= OrientNormal || o > OrientRotate270 {
+		return f
+	}
+	b := f.Bounds()
+	v := &fOrient{src: f, o: o, min: b.Min, sw: b.Dx(), sh: b.Dy(), b: image.Rect(0, 0, b.Dx(), b.Dy())}
+	if o >= OrientTranspose {
+		v.b = image.Rect(0, 0, b.Dy(), b.Dx())
+	}
+	return v
+}
+
+func (f *fOrient) ColorModel() color.Model {
+	return f.src.ColorModel()
+}
+
+func (f *fOrient) Bounds() image.Rectangle {
+	return f.b
+}
+
+func (f *fOrient) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(f.b)) {
+		return color.RGBA{}
+	}
+	return f.src.At(f.source(x, y))
+}
+
+// LuminanceAt returns the luminance of the source pixel.
+func (f *fOrient) LuminanceAt(x, y int) uint8 {
+	if !(image.Point{x, y}.In(f.b)) {
+		return 0
+	}
+	sx, sy := f.source(x, y)
+	return LuminanceAt(f.src, sx, sy)
+}
+
+// LuminanceImage returns the luminance of the view, transforming the
+// luminance of the source frame.
+func (f *fOrient) LuminanceImage() *image.Gray {
+	src := LuminanceImage(f.src)
+	g := image.NewGray(f.b)
+	for y := 0; y < f.b.Max.Y; y++ {
+		d := g.Pix[y*g.Stride : y*g.Stride+f.b.Max.X]
+		for x := range d {
+			sx, sy := f.source(x, y)
+			d[x] = src.Pix[src.PixOffset(sx, sy)]
+		}
+	}
+	return g
+}
+
+// source returns the coordinates in the source frame of a pixel of the view.
+func (f *fOrient) source(x, y int) (int, int) {
+	var sx, sy int
+	switch f.o {
+	case OrientFlipH:
+		sx, sy = f.sw-1-x, y
+	case OrientRotate180:
+		sx, sy = f.sw-1-x, f.sh-1-y
+	case OrientFlipV:
+		sx, sy = x, f.sh-1-y
+	case OrientTranspose:
+		sx, sy = y, x
+	case OrientRotate90:
+		sx, sy = y, f.sh-1-x
+	case OrientTransverse:
+		sx, sy = f.sw-1-y, f.sh-1-x
+	case OrientRotate270:
+		sx, sy = f.sw-1-y, x
+	}
+	return f.min.X + sx, f.min.Y + sy
+}
+
+func (f *fOrient) Release() {
+	f.src.Release()
+}
+
+func (f *fOrient) Timestamp() time.Time {
+	return TimestampOf(f.src)
+}
+
+func (f *fOrient) Sequence() uint32 {
+	return SequenceOf(f.src)
+}
+
+// Err returns the error from decoding the source frame.
+func (f *fOrient) Err() error {
+	return DecodeErr(f.src)
+}
+
+func (f *fOrient) Tag() interface{} {
+	return TagOf(f.src)
+}
+
+func (f *fOrient) setTag(v interface{}) {
+	WithTag(v)(f.src)
+}
+
+// Orientation returns the transform applied to the source frame.
+func (f *fOrient) Orientation() Orientation {
+	return f.o
+}
+
+func (f *fOrient) Framer() func([]byte, func()) (Frame, error) {
+	return FramerOf(f.src)
+}
+
+func (f *fOrient) setFramer(fn func([]byte, func()) (Frame, error)) {
+	WithFramer(fn)(f.src)
+}
+
+// Raw returns the raw data of the untransformed source frame.
+func (f *fOrient) Raw() []byte {
+	return RawOf(f.src)
+}
+
+func (f *fOrient) Fingerprint() uint64 {
+	return FingerprintOf(f.src)
+}
+
+// CopyToBuffer copies the image to dst as RGBA.
+func (f *fOrient) CopyToBuffer(dst []byte) (int, error) {
+	return copyRGBA(f, dst)
+}
@@ -0,0 +1,33 @@
+package frame
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+// Overlay returns a copy of the base frame with the inset frame drawn
+// on top of it, with the inset's top left corner placed at the given point.
+// Any part of the inset that extends past the bounds of the base is clipped.
+// Neither frame is released.
+func Overlay(base Frame, inset Frame, at image.Point) (*image.RGBA, error) {
+	if base == nil || inset == nil {
+		return nil, fmt.Errorf("Overlay: missing frame")
+	}
+	dst := toRGBA(base)
+	ib := inset.Bounds()
+	r := image.Rectangle{at, at.Add(ib.Size())}
+	draw.Draw(dst, r, inset, ib.Min, draw.Over)
+	return dst, nil
+}
+
+// toRGBA returns a copy of the image as an RGBA image.
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(b)
+	if w, ok := img.(RGBAWriter); ok && w.WriteRGBA(dst) == nil {
+		return dst
+	}
+	draw.Draw(dst, b, img, b.Min, draw.Src)
+	return dst
+}
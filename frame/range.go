@@ -0,0 +1,82 @@
+package frame
+
+import (
+	"math"
+	"sync"
+)
+
+// Tables expanding limited range luma (16-235) and chroma (16-240)
+// to full range.
+var limitedY, limitedC [256]uint8
+
+func init() {
+	for i := range limitedY {
+		limitedY[i] = clamp8(int32(math.Round(float64(i-16) * 255 / 219)))
+		limitedC[i] = clamp8(int32(math.Round(128 + float64(i-128)*255/224)))
+	}
+}
+
+// rangePool holds the buffers of full range copies of frames, which are
+// returned to the pool when the frame is released, so that decoding
+// limited range frames does not allocate for each frame.
+var rangePool sync.Pool
+
+// rangeBuf returns a buffer of n bytes from the pool.
+func rangeBuf(n int) *[]byte {
+	if p, ok := rangePool.Get().(*[]byte); ok && cap(*p) >= n {
+		*p = (*p)[:n]
+		return p
+	}
+	b := make([]byte, n)
+	return &b
+}
+
+// expandRange writes a full range copy of limited range data to dst,
+// using luts[i%len(luts)] for byte i.
+func expandRange(dst, src []byte, luts ...*[256]uint8) {
+	for i, v := range src {
+		dst[i] = luts[i%len(luts)][v]
+	}
+}
+
+// setLimitedRange replaces the frame data with a full range copy.
+func (f *fYUYV422) setLimitedRange() {
+	if f.expanded == nil {
+		f.expanded = rangeBuf(len(f.frame))
+	}
+	expandRange(*f.expanded, f.frame, &limitedY, &limitedC)
+	f.frame = *f.expanded
+}
+
+// Release releases the frame, returning any full range copy to the pool.
+func (f *fYUYV422) Release() {
+	f.Base.Release()
+	if f.expanded != nil {
+		rangePool.Put(f.expanded)
+		f.expanded = nil
+	}
+}
+
+// setLimitedRange replaces the planes with full range copies, which
+// share a single buffer.
+func (f *fYUV420) setLimitedRange() {
+	img := f.img
+	ny, nc := len(img.Y), len(img.Cb)
+	if f.expanded == nil {
+		f.expanded = rangeBuf(ny + 2*nc)
+	}
+	b := *f.expanded
+	expandRange(b[:ny], img.Y, &limitedY)
+	expandRange(b[ny:ny+nc], img.Cb, &limitedC)
+	expandRange(b[ny+nc:], img.Cr, &limitedC)
+	img.Y, img.Cb, img.Cr = b[:ny], b[ny:ny+nc], b[ny+nc:]
+}
+
+// Release releases the frame, returning any full range copy to the pool.
+func (f *fYUV420) Release() {
+	f.Base.Release()
+	if f.expanded != nil {
+		rangePool.Put(f.expanded)
+		f.expanded = nil
+	}
+}
@@ -1,59 +1,111 @@
 package frame
 
 import (
-	"fmt"
 	"image"
 	"image/color"
-	"runtime"
 )
 
 type fRGB struct {
-	model   color.Model
-	b       image.Rectangle
-	stride  int
-	roffs   int
-	goffs   int
-	boffs   int
-	frame   []byte
-	release func()
+	model  color.Model
+	b      image.Rectangle
+	stride int
+	pixel  int
+	roffs  int
+	goffs  int
+	boffs  int
+	aoffs  int // Offset of the alpha byte, or -1 if opaque.
+	frame  []byte
+	Base
 }
 
 // Register framers for these formats.
 func init() {
 	RegisterFramer("RGB3", newFramerRGB3)
 	RegisterFramer("BGR3", newFramerBGR3)
+	RegisterFramer("XR24", newFramerXR24)
+	RegisterFramer("BX24", newFramerBX24)
+	RegisterFramer("AR24", newFramerAR24)
+	RegisterFramer("AB24", newFramerAB24)
+	RegisterFramer("BA24", newFramerBA24)
 }
 
 // Return a function that is used as a framer for RGB3.
 func newFramerRGB3(w, h, stride, size int) func([]byte, func()) (Frame, error) {
-	return newRGBFramer(w, h, stride, size, 0, 1, 2)
+	return newRGBFramer("RGB3", w, h, stride, size, 0, 1, 2)
 }
 
 // Return a function that is used as a framer for BGR3.
 func newFramerBGR3(w, h, stride, size int) func([]byte, func()) (Frame, error) {
-	return newRGBFramer(w, h, stride, size, 2, 1, 0)
+	return newRGBFramer("BGR3", w, h, stride, size, 2, 1, 0)
+}
+
+// Return a function that is used as a framer for XR24.
+// Each pixel is stored as B, G, R and a padding byte.
+func newFramerXR24(w, h, stride, size int) func([]byte, func()) (Frame, error) {
+	return newRGBFramer("XR24", w, h, stride, size, 2, 1, 0)
+}
+
+// Return a function that is used as a framer for BX24.
+// Each pixel is stored as a padding byte followed by R, G, B.
+func newFramerBX24(w, h, stride, size int) func([]byte, func()) (Frame, error) {
+	return newRGBFramer("BX24", w, h, stride, size, 1, 2, 3)
+}
+
+// Return a function that is used as a framer for AR24 (ABGR32).
+// Each pixel is stored as B, G, R, A.
+func newFramerAR24(w, h, stride, size int) func([]byte, func()) (Frame, error) {
+	return newAlphaFramer("AR24", w, h, stride, size, 2, 1, 0, 3)
+}
+
+// Return a function that is used as a framer for AB24 (RGBA32).
+// Each pixel is stored as R, G, B, A.
+func newFramerAB24(w, h, stride, size int) func([]byte, func()) (Frame, error) {
+	return newAlphaFramer("AB24", w, h, stride, size, 0, 1, 2, 3)
+}
+
+// Return a function that is used as a framer for BA24 (ARGB32).
+// Each pixel is stored as A, R, G, B.
+func newFramerBA24(w, h, stride, size int) func([]byte, func()) (Frame, error) {
+	return newAlphaFramer("BA24", w, h, stride, size, 1, 2, 3, 0)
 }
 
 // Return a function that is used as a generic RGB framer.
-func newRGBFramer(w, h, stride, size, r, g, b int) func([]byte, func()) (Frame, error) {
+// r, g and b are the offsets of the components in each pixel.
+func newRGBFramer(format FourCC, w, h, stride, size, r, g, b int) func([]byte, func()) (Frame, error) {
+	if err := checkLayout(format, w, h, stride, size); err != nil {
+		return failFramer(err)
+	}
+	pixel := bytesPerPixel[format]
+	return func(buf []byte, rel func()) (Frame, error) {
+		return frameRGB(size, stride, w, h, pixel, r, g, b, -1, buf, rel)
+	}
+}
+
+// Return a function that is used as a framer for 4 byte pixels
+// with a (non-premultiplied) alpha channel.
+func newAlphaFramer(format FourCC, w, h, stride, size, r, g, b, a int) func([]byte, func()) (Frame, error) {
+	if err := checkLayout(format, w, h, stride, size); err != nil {
+		return failFramer(err)
+	}
 	return func(buf []byte, rel func()) (Frame, error) {
-		return frameRGB(size, stride, w, h, r, g, b, buf, rel)
+		return frameRGB(size, stride, w, h, 4, r, g, b, a, buf, rel)
 	}
 }
 
 // Wrap a raw webcam frame in a Frame so that it can be used as an image.
-func frameRGB(size, stride, w, h, rof, gof, bof int, b []byte, rel func()) (Frame, error) {
-	if len(b) != size {
-		if rel != nil {
-			defer rel()
-		}
-		return nil, fmt.Errorf("Wrong frame length (exp: %d, read %d)", size, len(b))
+// If aof is negative, the frame is opaque.
+func frameRGB(size, stride, w, h, pixel, rof, gof, bof, aof int, b []byte, rel func()) (Frame, error) {
+	if err := CheckFrame(b, size, rel); err != nil {
+		return nil, err
+	}
+	model := color.RGBAModel
+	if aof >= 0 {
+		// The alpha is not premultiplied, so NRGBA represents it exactly.
+		model = color.NRGBAModel
 	}
-	f := &fRGB{model: color.RGBAModel, b: image.Rect(0, 0, w, h), stride: stride,
-		roffs: rof, goffs: gof, boffs: bof, frame: b, release: rel}
-	runtime.SetFinalizer(f, func(obj Frame) {
-		obj.Release()
-	})
+	f := &fRGB{model: model, b: image.Rect(0, 0, w, h), stride: stride,
+		pixel: pixel, roffs: rof, goffs: gof, boffs: bof, aoffs: aof, frame: b, Base: NewBase(b, rel)}
+	SetReleaseFinalizer(f)
 	return f, nil
 }
 
@@ -66,15 +118,93 @@ func (f *fRGB) Bounds() image.Rectangle {
 }
 
 func (f *fRGB) At(x, y int) color.Color {
-	i := f.stride*y + x*3
-	return color.RGBA{f.frame[i+f.roffs], f.frame[i+f.goffs], f.frame[i+f.boffs], 0xFF}
+	i := f.stride*y + x*f.pixel
+	c := color.NRGBA{f.frame[i+f.roffs], f.frame[i+f.goffs], f.frame[i+f.boffs], 0xFF}
+	if f.aoffs >= 0 {
+		c.A = f.frame[i+f.aoffs]
+	}
+	if f.model == color.NRGBAModel {
+		return c
+	}
+	if c.A == 0xFF {
+		return color.RGBA{c.R, c.G, c.B, 0xFF}
+	}
+	return color.RGBAModel.Convert(c)
+}
+
+// Opaque reports whether the frame is fully opaque, allowing encoders
+// to skip the alpha channel. Only formats with an alpha channel are scanned.
+func (f *fRGB) Opaque() bool {
+	if f.aoffs < 0 {
+		return true
+	}
+	for y := 0; y < f.b.Max.Y; y++ {
+		row := f.frame[f.stride*y:]
+		for x := 0; x < f.b.Max.X; x++ {
+			if row[x*f.pixel+f.aoffs] != 0xFF {
+				return false
+			}
+		}
+	}
+	return true
 }
 
-// Done with frame, release back to camera (if required).
-func (f *fRGB) Release() {
-	if f.release != nil {
-		f.release()
-		// Make sure it only gets called once.
-		f.release = nil
+// setColorModel selects either the RGBA or NRGBA color model.
+func (f *fRGB) setColorModel(m color.Model) {
+	if m == color.NRGBAModel {
+		f.model = color.NRGBAModel
+	} else {
+		f.model = color.RGBAModel
+	}
+}
+
+// swapRB exchanges the red and blue channels.
+func (f *fRGB) swapRB() {
+	f.roffs, f.boffs = f.boffs, f.roffs
+}
+
+// WriteRGBA copies the frame to dst. Any alpha channel is
+// premultiplied, as required by image.RGBA.
+func (f *fRGB) WriteRGBA(dst *image.RGBA) error {
+	if err := checkDst(dst, f.b); err != nil {
+		return err
+	}
+	for y := 0; y < f.b.Max.Y; y++ {
+		src := f.frame[f.stride*y:]
+		d := dst.Pix[dst.PixOffset(0, y):]
+		for x := 0; x < f.b.Max.X; x++ {
+			if f.aoffs >= 0 {
+				a := uint32(src[f.aoffs])
+				d[0] = uint8((uint32(src[f.roffs])*a + 127) / 255)
+				d[1] = uint8((uint32(src[f.goffs])*a + 127) / 255)
+				d[2] = uint8((uint32(src[f.boffs])*a + 127) / 255)
+				d[3] = uint8(a)
+			} else {
+				d[0], d[1], d[2], d[3] = src[f.roffs], src[f.goffs], src[f.boffs], 0xFF
+			}
+			src = src[f.pixel:]
+			d = d[4:]
+		}
+	}
+	return nil
+}
+
+// LuminanceAt returns the luminance of the pixel.
+func (f *fRGB) LuminanceAt(x, y int) uint8 {
+	i := f.stride*y + x*f.pixel
+	return rgbLuma(f.frame[i+f.roffs], f.frame[i+f.goffs], f.frame[i+f.boffs])
+}
+
+// LuminanceImage returns the luminance of the frame.
+func (f *fRGB) LuminanceImage() *image.Gray {
+	g := image.NewGray(f.b)
+	for y := 0; y < f.b.Max.Y; y++ {
+		src := f.frame[f.stride*y:]
+		d := g.Pix[g.PixOffset(0, y):]
+		for x := 0; x < f.b.Max.X; x++ {
+			p := src[x*f.pixel:]
+			d[x] = rgbLuma(p[f.roffs], p[f.goffs], p[f.boffs])
+		}
 	}
+	return g
 }
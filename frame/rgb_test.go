@@ -0,0 +1,61 @@
+package frame
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestXR24Layout(t *testing.T) {
+	// Two XR24 pixels, stored as B, G, R and an unused padding byte.
+	raw := []byte{0x10, 0x20, 0x30, 0xAA, 0x40, 0x50, 0x60, 0xBB}
+	for _, tc := range []struct {
+		format FourCC
+		want   []color.RGBA
+	}{
+		{"XR24", []color.RGBA{{0x30, 0x20, 0x10, 0xFF}, {0x60, 0x50, 0x40, 0xFF}}},
+		// BX24 has the padding byte first, then R, G and B.
+		{"BX24", []color.RGBA{{0x20, 0x30, 0xAA, 0xFF}, {0x50, 0x60, 0xBB, 0xFF}}},
+	} {
+		framer, err := GetFramer(tc.format, 2, 1, 8, len(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := framer(append([]byte(nil), raw...), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := image.NewRGBA(f.Bounds())
+		if err := f.(RGBAWriter).WriteRGBA(dst); err != nil {
+			t.Fatal(err)
+		}
+		for x, want := range tc.want {
+			// The padding byte is not used as alpha.
+			if got := color.RGBAModel.Convert(f.At(x, 0)).(color.RGBA); got != want {
+				t.Errorf("%s: At(%d, 0): got %v, want %v", tc.format, x, got, want)
+			}
+			if got := dst.RGBAAt(x, 0); got != want {
+				t.Errorf("%s: WriteRGBA pixel %d: got %v, want %v", tc.format, x, got, want)
+			}
+		}
+		f.Release()
+	}
+	// Converting to XR24 stores the components in the same order,
+	// with a zero padding byte.
+	src, err := GetFramer("RGB3", 2, 1, 6, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := src([]byte{0x30, 0x20, 0x10, 0x60, 0x50, 0x40}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := Convert(f, "XR24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0x10, 0x20, 0x30, 0, 0x40, 0x50, 0x60, 0}; !bytes.Equal(RawOf(x), want) {
+		t.Fatalf("Convert to XR24: got % x, want % x", RawOf(x), want)
+	}
+}
@@ -0,0 +1,81 @@
+package frame
+
+import (
+	"fmt"
+)
+
+// Bytes per pixel of the uncompressed packed formats.
+var bytesPerPixel = map[FourCC]int{
+	"RGB3": 3,
+	"BGR3": 3,
+	"XR24": 4,
+	"BX24": 4,
+	"AR24": 4,
+	"AB24": 4,
+	"BA24": 4,
+	"YUYV": 2,
+	"GREY": 1,
+	"RGGB": 1,
+	"BA81": 1,
+	"GRBG": 1,
+	"GBRG": 1,
+}
+
+// The planar 4:2:0 formats, which have a luma plane followed by two chroma
+// planes of half the stride and height, rounded up.
+var planar420 = map[FourCC]bool{
+	"YU12": true,
+	"YV12": true,
+}
+
+// FrameSizeBytes returns the size in bytes of a frame of the format and
+// resolution. If stride is 0, the minimum stride for the width is used.
+// For planar formats, stride is that of the luma plane.
+// An error is returned for unknown or compressed formats, which
+// do not have a fixed size.
+func FrameSizeBytes(format FourCC, w, h, stride int) (int, error) {
+	bpp, ok := bytesPerPixel[format]
+	if planar420[format] {
+		bpp, ok = 1, true
+	}
+	if !ok {
+		return 0, fmt.Errorf("%s: no fixed frame size for format", format)
+	}
+	if w <= 0 || h <= 0 {
+		return 0, fmt.Errorf("illegal resolution %dx%d", w, h)
+	}
+	if stride == 0 {
+		stride = w * bpp
+	} else if stride < w*bpp {
+		return 0, fmt.Errorf("stride %d too small for width %d", stride, w)
+	}
+	if planar420[format] {
+		return stride*h + 2*((stride+1)/2)*((h+1)/2), nil
+	}
+	return stride * h, nil
+}
+
+// checkLayout returns an error if frames of size bytes are too short to
+// hold the format at the resolution and stride, so that framers do not
+// read beyond the end of a frame, e.g when the stride is overridden.
+func checkLayout(format FourCC, w, h, stride, size int) error {
+	need, err := FrameSizeBytes(format, w, h, stride)
+	if err != nil {
+		return err
+	}
+	if size < need {
+		return fmt.Errorf("%s: frame size %d too short for %dx%d with stride %d (need %d)", format, size, w, h, stride, need)
+	}
+	return nil
+}
+
+// failFramer returns a framer that releases each frame and returns err,
+// for use by framer factories given a layout that cannot be decoded.
+func failFramer(err error) func([]byte, func()) (Frame, error) {
+	return func(b []byte, rel func()) (Frame, error) {
+		if rel != nil {
+			rel()
+		}
+		return nil, err
+	}
+}
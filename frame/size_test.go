@@ -0,0 +1,95 @@
+package frame
+
+import (
+	"testing"
+)
+
+// Formats without a fixed frame size.
+var compressedFormats = map[FourCC]bool{
+	"JPEG": true,
+	"MJPG": true,
+	"H264": true,
+	"VP80": true,
+	"VP90": true,
+}
+
+// minStride returns the minimum stride of a row of the format.
+func minStride(format FourCC, w int) int {
+	if planar420[format] {
+		return w
+	}
+	return w * bytesPerPixel[format]
+}
+
+func TestFrameSizeBytes(t *testing.T) {
+	layouts := []struct {
+		w, h, pad int
+	}{
+		{8, 4, 0},
+		{3, 3, 0},
+		{5, 2, 0},
+		{1, 1, 0},
+		{6, 4, 10},
+		{7, 5, 3},
+	}
+	for format := range framerFactoryMap {
+		format := format
+		t.Run(string(format), func(t *testing.T) {
+			h, data := readSample(t, format)
+			size, err := FrameSizeBytes(format, int(h.Width), int(h.Height), int(h.Stride))
+			if compressedFormats[format] {
+				if err == nil {
+					t.Fatalf("got size %d for compressed format", size)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size != len(data) {
+				t.Errorf("sample: got size %d, sample has %d bytes", size, len(data))
+			}
+			for _, l := range layouts {
+				stride := minStride(format, l.w) + l.pad
+				size, err := FrameSizeBytes(format, l.w, l.h, stride)
+				if err != nil {
+					t.Fatalf("%dx%d stride %d: %v", l.w, l.h, stride, err)
+				}
+				if l.pad == 0 {
+					if n, _ := FrameSizeBytes(format, l.w, l.h, 0); n != size {
+						t.Errorf("%dx%d: got size %d for stride 0, want %d", l.w, l.h, n, size)
+					}
+				}
+				// The framer reads every pixel of a frame of that size.
+				framer, err := GetFramer(format, l.w, l.h, stride, size)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f, err := framer(make([]byte, size), nil)
+				if err != nil {
+					t.Fatalf("%dx%d stride %d: %v", l.w, l.h, stride, err)
+				}
+				rgbaChecksum(f)
+				f.Release()
+				// And rejects frames that are a byte short.
+				framer, err = GetFramer(format, l.w, l.h, stride, size-1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				released := 0
+				if _, err := framer(make([]byte, size-1), func() { released++ }); err == nil {
+					t.Errorf("%dx%d stride %d: short frame accepted", l.w, l.h, stride)
+				}
+				if released != 1 {
+					t.Errorf("%dx%d stride %d: release called %d times", l.w, l.h, stride, released)
+				}
+			}
+		})
+	}
+	if _, err := FrameSizeBytes("YUYV", 4, 2, 7); err == nil {
+		t.Error("stride less than width accepted")
+	}
+	if _, err := FrameSizeBytes("YUYV", 0, 2, 0); err == nil {
+		t.Error("zero width accepted")
+	}
+}
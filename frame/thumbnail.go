@@ -0,0 +1,57 @@
+package frame
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+
+	"golang.org/x/image/draw"
+)
+
+// Quality used for thumbnails, which are small enough that
+// artifacts are not noticeable.
+const thumbnailQuality = 75
+
+// ThumbnailJPEG returns a JPEG of the image scaled so that its longest
+// edge is at most maxDim pixels, preserving the aspect ratio. Images that
+// already fit are not enlarged. MJPEG frames are scaled directly from the
+// decoded YCbCr image, avoiding an intermediate full-size RGB copy.
+func ThumbnailJPEG(img image.Image, maxDim int) ([]byte, error) {
+	if maxDim <= 0 {
+		return nil, fmt.Errorf("illegal thumbnail size %d", maxDim)
+	}
+	if e, ok := img.(Decoder); ok {
+		if err := e.Err(); err != nil {
+			return nil, err
+		}
+	}
+	src := AsDrawSource(img)
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("empty image")
+	}
+	if w > maxDim || h > maxDim {
+		if w >= h {
+			w, h = maxDim, max1(h*maxDim/w)
+		} else {
+			w, h = max1(w*maxDim/h), maxDim
+		}
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+		src = dst
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func max1(v int) int {
+	if v < 1 {
+		return 1
+	}
+	return v
+}
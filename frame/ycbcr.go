@@ -0,0 +1,52 @@
+package frame
+
+import (
+	"image"
+	"image/color"
+)
+
+// ToYCbCr converts the image to a 4:2:0 YCbCr image.
+// Images that are natively YCbCr (such as YUYV frames) are copied
+// without conversion through RGB, and YU12/YV12 frames are copied directly.
+func ToYCbCr(img image.Image) *image.YCbCr {
+	if p, ok := img.(interface{ YCbCr() *image.YCbCr }); ok {
+		src := p.YCbCr()
+		if src.SubsampleRatio == image.YCbCrSubsampleRatio420 {
+			dst := image.NewYCbCr(src.Rect, src.SubsampleRatio)
+			copyPlane(dst.Y, dst.YStride, src.Y, src.YStride, src.Rect.Dx(), src.Rect.Dy())
+			cw, ch := (src.Rect.Dx()+1)/2, (src.Rect.Dy()+1)/2
+			copyPlane(dst.Cb, dst.CStride, src.Cb, src.CStride, cw, ch)
+			copyPlane(dst.Cr, dst.CStride, src.Cr, src.CStride, cw, ch)
+			return dst
+		}
+	}
+	b := img.Bounds()
+	dst := image.NewYCbCr(b, image.YCbCrSubsampleRatio420)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := toYCbCrColor(img.At(x, y))
+			dst.Y[dst.YOffset(x, y)] = c.Y
+			// Take the chroma from the top left pixel of each 2x2 block.
+			if (x-b.Min.X)&1 == 0 && (y-b.Min.Y)&1 == 0 {
+				ci := dst.COffset(x, y)
+				dst.Cb[ci] = c.Cb
+				dst.Cr[ci] = c.Cr
+			}
+		}
+	}
+	return dst
+}
+
+func toYCbCrColor(c color.Color) color.YCbCr {
+	if yc, ok := c.(color.YCbCr); ok {
+		return yc
+	}
+	return color.YCbCrModel.Convert(c).(color.YCbCr)
+}
+
+// copyPlane copies w by h bytes between planes of different strides.
+func copyPlane(dst []byte, dstStride int, src []byte, srcStride, w, h int) {
+	for y := 0; y < h; y++ {
+		copy(dst[y*dstStride:y*dstStride+w], src[y*srcStride:])
+	}
+}
@@ -0,0 +1,92 @@
+package frame
+
+import (
+	"image"
+	"image/color"
+)
+
+type fYUV420 struct {
+	img *image.YCbCr
+	// Full range copy of the planes from rangePool, if LimitedRange is used.
+	expanded *[]byte
+	Base
+}
+
+// Register framers for the planar 4:2:0 formats.
+func init() {
+	RegisterFramer("YU12", newFramerYU12)
+	RegisterFramer("YV12", newFramerYV12)
+}
+
+// Return a function that is used as a framer for YU12 (I420),
+// stored as the Y plane followed by the Cb and Cr planes.
+func newFramerYU12(w, h, stride, size int) func([]byte, func()) (Frame, error) {
+	if err := checkLayout("YU12", w, h, stride, size); err != nil {
+		return failFramer(err)
+	}
+	return func(b []byte, rel func()) (Frame, error) {
+		return frameYUV420(size, stride, w, h, false, b, rel)
+	}
+}
+
+// Return a function that is used as a framer for YV12,
+// stored as the Y plane followed by the Cr and Cb planes.
+func newFramerYV12(w, h, stride, size int) func([]byte, func()) (Frame, error) {
+	if err := checkLayout("YV12", w, h, stride, size); err != nil {
+		return failFramer(err)
+	}
+	return func(b []byte, rel func()) (Frame, error) {
+		return frameYUV420(size, stride, w, h, true, b, rel)
+	}
+}
+
+// Wrap a raw webcam frame in a Frame so that it can be used as an image.
+// The chroma planes have half the stride of the luma plane, rounded up
+// so that odd widths and heights have chroma for the last column and row.
+// The framer has checked that the frame size is large enough for the planes.
+func frameYUV420(size, stride, w, h int, swap bool, b []byte, rel func()) (Frame, error) {
+	if err := CheckFrame(b, size, rel); err != nil {
+		return nil, err
+	}
+	ySize := stride * h
+	cStride := (stride + 1) / 2
+	cSize := cStride * ((h + 1) / 2)
+	cb, cr := b[ySize:ySize+cSize], b[ySize+cSize:ySize+2*cSize]
+	if swap {
+		cb, cr = cr, cb
+	}
+	img := &image.YCbCr{Y: b[:ySize], Cb: cb, Cr: cr, YStride: stride, CStride: cStride,
+		SubsampleRatio: image.YCbCrSubsampleRatio420, Rect: image.Rect(0, 0, w, h)}
+	f := &fYUV420{img: img, Base: NewBase(b, rel)}
+	SetReleaseFinalizer(f)
+	return f, nil
+}
+
+func (f *fYUV420) ColorModel() color.Model {
+	return color.YCbCrModel
+}
+
+func (f *fYUV420) Bounds() image.Rectangle {
+	return f.img.Rect
+}
+
+func (f *fYUV420) At(x, y int) color.Color {
+	return f.img.YCbCrAt(x, y)
+}
+
+// YCbCr returns the frame as an image.YCbCr that refers to the frame
+// buffer, so it is only valid until the frame is released. This allows
+// encoders such as image/jpeg to use their YCbCr fast path.
+func (f *fYUV420) YCbCr() *image.YCbCr {
+	return f.img
+}
+
+// LuminanceAt returns the Y value of the pixel.
+func (f *fYUV420) LuminanceAt(x, y int) uint8 {
+	return f.img.Y[f.img.YOffset(x, y)]
+}
+
+// LuminanceImage copies the luma plane of the frame.
+func (f *fYUV420) LuminanceImage() *image.Gray {
+	return yPlane(f.img)
+}
@@ -0,0 +1,172 @@
+package frame
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestYUYVOddWidth(t *testing.T) {
+	// Rows of 3 pixels: Y0 U Y1 V Y2 U' and, if padded, the missing Y3 V'.
+	rows := [][]byte{
+		{10, 100, 11, 200, 12, 101, 0, 201},
+		{20, 110, 21, 210, 22, 111, 0, 211},
+		{30, 120, 31, 220, 32, 121, 0, 221},
+	}
+	for _, stride := range []int{6, 8} {
+		var b []byte
+		for _, r := range rows {
+			b = append(b, r[:stride]...)
+		}
+		f, err := frameYUYV422(len(b), stride, 3, 3, b, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Bounds() != image.Rect(0, 0, 3, 3) {
+			t.Fatalf("stride %d: bounds %v", stride, f.Bounds())
+		}
+		for y, r := range rows {
+			// The only complete pair is the first, so an unpadded row
+			// uses its chroma for the last pixel.
+			last := color.YCbCr{r[4], r[1], r[3]}
+			if stride == 8 {
+				last = color.YCbCr{r[4], r[5], r[7]}
+			}
+			want := []color.YCbCr{{r[0], r[1], r[3]}, {r[2], r[1], r[3]}, last}
+			for x, w := range want {
+				if got := f.At(x, y); got != w {
+					t.Errorf("stride %d: At(%d, %d): got %v, want %v", stride, x, y, got, w)
+				}
+				if l := LuminanceAt(f, x, y); l != w.Y {
+					t.Errorf("stride %d: LuminanceAt(%d, %d): got %d, want %d", stride, x, y, l, w.Y)
+				}
+			}
+		}
+		g := LuminanceImage(f)
+		if g.Pix[g.PixOffset(2, 2)] != 32 {
+			t.Errorf("stride %d: LuminanceImage: got %v", stride, g.Pix)
+		}
+	}
+}
+
+func TestYUV420OddSize(t *testing.T) {
+	y := []byte{
+		1, 2, 3,
+		4, 5, 6,
+		7, 8, 9,
+	}
+	// 2x2 chroma planes, the last column and row covering one pixel.
+	u := []byte{100, 101, 102, 103}
+	v := []byte{200, 201, 202, 203}
+	for _, tc := range []struct {
+		format FourCC
+		planes [][]byte
+	}{
+		{"YU12", [][]byte{y, u, v}},
+		{"YV12", [][]byte{y, v, u}},
+	} {
+		var b []byte
+		for _, p := range tc.planes {
+			b = append(b, p...)
+		}
+		framer, err := GetFramer(tc.format, 3, 3, 3, len(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := framer(b, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.format, err)
+		}
+		for py := 0; py < 3; py++ {
+			for px := 0; px < 3; px++ {
+				c := py/2*2 + px/2
+				want := color.YCbCr{y[py*3+px], u[c], v[c]}
+				if got := f.At(px, py); got != want {
+					t.Errorf("%s: At(%d, %d): got %v, want %v", tc.format, px, py, got, want)
+				}
+			}
+		}
+		if g := LuminanceImage(f); string(g.Pix) != string(y) {
+			t.Errorf("%s: LuminanceImage: got %v, want %v", tc.format, g.Pix, y)
+		}
+	}
+}
+
+func TestYUV420Short(t *testing.T) {
+	released := false
+	framer, err := GetFramer("YU12", 3, 3, 3, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := framer(make([]byte, 16), func() { released = true }); err == nil {
+		t.Fatal("no error for frame without room for the chroma planes")
+	}
+	if !released {
+		t.Fatal("short frame not released")
+	}
+}
+
+func TestYUVRange(t *testing.T) {
+	// Two YUYV pixels at the extremes of full range.
+	raw := []byte{0, 16, 255, 240}
+	f, err := frameYUYV422(len(raw), 4, 2, 1, raw, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Full range data decodes without clipping.
+	for x, want := range []color.YCbCr{{0, 16, 240}, {255, 16, 240}} {
+		if got := f.At(x, 0); got != want {
+			t.Errorf("full range: At(%d, 0): got %v, want %v", x, got, want)
+		}
+	}
+	f.Release()
+	framer, err := GetFramer("YUYV", 2, 1, 4, 4, LimitedRange())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The expanded buffer is reused once a frame is released.
+	for _, raw := range [][]byte{{16, 16, 235, 240}, {235, 128, 16, 128}} {
+		b := append([]byte(nil), raw...)
+		f, err := framer(b, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for x := 0; x < 2; x++ {
+			want := color.YCbCr{limitedY[raw[x*2]], limitedC[raw[1]], limitedC[raw[3]]}
+			if got := f.At(x, 0); got != want {
+				t.Errorf("limited range: At(%d, 0): got %v, want %v", x, got, want)
+			}
+		}
+		if string(RawOf(f)) != string(raw) {
+			t.Errorf("limited range: Raw changed to %v", RawOf(f))
+		}
+		f.Release()
+	}
+	if limitedY[16] != 0 || limitedY[235] != 255 || limitedC[240] != 255 {
+		t.Error("limited range not expanded to full range")
+	}
+}
+
+func TestYUV420Range(t *testing.T) {
+	framer, err := GetFramer("YU12", 2, 2, 2, 6, LimitedRange())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, raw := range [][]byte{{16, 50, 100, 235, 16, 240}, {235, 100, 50, 16, 128, 128}} {
+		b := append([]byte(nil), raw...)
+		f, err := framer(b, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 4; i++ {
+			want := color.YCbCr{limitedY[raw[i]], limitedC[raw[4]], limitedC[raw[5]]}
+			if got := f.At(i%2, i/2); got != want {
+				t.Errorf("At(%d, %d): got %v, want %v", i%2, i/2, got, want)
+			}
+		}
+		if string(RawOf(f)) != string(raw) {
+			t.Errorf("Raw changed to %v", RawOf(f))
+		}
+		f.Release()
+	}
+}
@@ -1,19 +1,19 @@
 package frame
 
 import (
-	"fmt"
 	"image"
 	"image/color"
-	"runtime"
 )
 
 type fYUYV422 struct {
-	model   color.Model
-	b       image.Rectangle
-	stride  int
-	size    int
-	frame   []byte
-	release func()
+	model  color.Model
+	b      image.Rectangle
+	stride int
+	size   int
+	frame  []byte
+	// Full range copy of the frame from rangePool, if LimitedRange is used.
+	expanded *[]byte
+	Base
 }
 
 // Register a framer factory for this format.
@@ -22,6 +22,9 @@ func init() {
 }
 
 func newFramerYUYV422(w, h, stride, size int) func([]byte, func()) (Frame, error) {
+	if err := checkLayout("YUYV", w, h, stride, size); err != nil {
+		return failFramer(err)
+	}
 	return func(b []byte, rel func()) (Frame, error) {
 		return frameYUYV422(size, stride, w, h, b, rel)
 	}
@@ -29,16 +32,11 @@ func newFramerYUYV422(w, h, stride, size int) func([]byte, func()) (Frame, error
 
 // Wrap a raw webcam frame in a Frame so that it can be used as an image.
 func frameYUYV422(size, stride, w, h int, b []byte, rel func()) (Frame, error) {
-	if len(b) != size {
-		if rel != nil {
-			defer rel()
-		}
-		return nil, fmt.Errorf("Wrong frame length (exp: %d, read %d)", size, len(b))
+	if err := CheckFrame(b, size, rel); err != nil {
+		return nil, err
 	}
-	f := &fYUYV422{model: color.YCbCrModel, b: image.Rect(0, 0, w, h), stride: stride, frame: b, release: rel}
-	runtime.SetFinalizer(f, func(obj Frame) {
-		obj.Release()
-	})
+	f := &fYUYV422{model: color.YCbCrModel, b: image.Rect(0, 0, w, h), stride: stride, frame: b, Base: NewBase(b, rel)}
+	SetReleaseFinalizer(f)
 	return f, nil
 }
 
@@ -51,19 +49,72 @@ func (f *fYUYV422) Bounds() image.Rectangle {
 }
 
 func (f *fYUYV422) At(x, y int) color.Color {
-	index := f.stride*y + (x&^1)*2
-	if x&1 == 0 {
-		return color.YCbCr{f.frame[index], f.frame[index+1], f.frame[index+3]}
-	} else {
-		return color.YCbCr{f.frame[index+2], f.frame[index+1], f.frame[index+3]}
+	row := f.row(y)
+	cb, cr := chroma422(row, x)
+	return color.YCbCr{row[x*2], cb, cr}
+}
+
+// row returns the data of a row of the frame.
+func (f *fYUYV422) row(y int) []byte {
+	row := f.frame[f.stride*y:]
+	if len(row) > f.stride {
+		row = row[:f.stride]
 	}
+	return row
 }
 
-// Done with frame, release back to camera (if required).
-func (f *fYUYV422) Release() {
-	if f.release != nil {
-		f.release()
-		// Make sure it only gets called once.
-		f.release = nil
+// chroma422 returns the chroma shared by the pair of pixels containing x.
+// If the width is odd and the row is not padded, the last pixel has no
+// complete pair, so the chroma of the previous pair is used (or neutral
+// Cr if the row is a single pixel wide).
+func chroma422(row []byte, x int) (uint8, uint8) {
+	i := (x &^ 1) * 2
+	switch {
+	case i+3 < len(row):
+		return row[i+1], row[i+3]
+	case i >= 4:
+		return row[i-3], row[i-1]
+	}
+	return row[i+1], 128
+}
+
+// WriteRGBA converts the frame to RGBA using integer arithmetic.
+func (f *fYUYV422) WriteRGBA(dst *image.RGBA) error {
+	if err := checkDst(dst, f.b); err != nil {
+		return err
+	}
+	for y := 0; y < f.b.Max.Y; y++ {
+		src := f.row(y)
+		d := dst.Pix[dst.PixOffset(0, y):]
+		for x := 0; x < f.b.Max.X; x += 2 {
+			// Both pixels of the pair share the chroma.
+			u, v := chroma422(src, x)
+			d[0], d[1], d[2] = ycbcrToRGB(src[x*2], u, v)
+			d[3] = 0xFF
+			if x+1 < f.b.Max.X {
+				d[4], d[5], d[6] = ycbcrToRGB(src[x*2+2], u, v)
+				d[7] = 0xFF
+				d = d[8:]
+			}
+		}
+	}
+	return nil
+}
+
+// LuminanceAt returns the Y value of the pixel.
+func (f *fYUYV422) LuminanceAt(x, y int) uint8 {
+	return f.frame[f.stride*y+x*2]
+}
+
+// LuminanceImage copies the Y values of the frame.
+func (f *fYUYV422) LuminanceImage() *image.Gray {
+	g := image.NewGray(f.b)
+	for y := 0; y < f.b.Max.Y; y++ {
+		src := f.frame[f.stride*y:]
+		d := g.Pix[g.PixOffset(0, y):]
+		for x := 0; x < f.b.Max.X; x++ {
+			d[x] = src[x*2]
+		}
 	}
+	return g
 }
@@ -2,4 +2,7 @@ module github.com/aamcrae/webcam
 
 go 1.16
 
-require golang.org/x/sys v0.0.0-20210616045830-e2b7044e8c71
+require (
+	golang.org/x/image v0.15.0
+	golang.org/x/sys v0.17.0
+)
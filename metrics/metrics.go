@@ -0,0 +1,74 @@
+// metrics provides a Prometheus collector for the capture statistics of a Snapper.
+package metrics
+
+import (
+	"time"
+
+	"github.com/aamcrae/webcam/snapshot"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector reports the statistics of a Snapper as Prometheus metrics.
+type Collector struct {
+	snapper   *snapshot.Snapper
+	captured  *prometheus.Desc
+	dropped   *prometheus.Desc
+	frameAge  *prometheus.Desc
+	fps       *prometheus.Desc
+	streaming *prometheus.Desc
+	queued    *prometheus.Desc
+	buffers   *prometheus.Desc
+}
+
+// NewCollector returns a collector for the Snapper. The device name is added
+// as a label so that multiple cameras can be registered.
+func NewCollector(s *snapshot.Snapper, device string) *Collector {
+	labels := prometheus.Labels{"device": device}
+	return &Collector{
+		snapper: s,
+		captured: prometheus.NewDesc("webcam_frames_captured_total",
+			"Number of frames read from the camera.", nil, labels),
+		dropped: prometheus.NewDesc("webcam_frames_dropped_total",
+			"Number of frames discarded because no-one was ready to read them.", nil, labels),
+		frameAge: prometheus.NewDesc("webcam_last_frame_age_seconds",
+			"Time since the last frame was read from the camera.", nil, labels),
+		fps: prometheus.NewDesc("webcam_frames_per_second",
+			"Average rate that frames are read from the camera.", nil, labels),
+		streaming: prometheus.NewDesc("webcam_streaming",
+			"1 if the camera is streaming, 0 otherwise.", nil, labels),
+		queued: prometheus.NewDesc("webcam_buffers_queued",
+			"Number of buffers queued with the driver for capture.", nil, labels),
+		buffers: prometheus.NewDesc("webcam_buffers",
+			"Number of buffers allocated by the driver.", nil, labels),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.captured
+	ch <- c.dropped
+	ch <- c.frameAge
+	ch <- c.fps
+	ch <- c.streaming
+	ch <- c.queued
+	ch <- c.buffers
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	st := c.snapper.Stats()
+	ch <- prometheus.MustNewConstMetric(c.captured, prometheus.CounterValue, float64(st.Captured))
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(st.Dropped))
+	if !st.LastFrame.IsZero() {
+		ch <- prometheus.MustNewConstMetric(c.frameAge, prometheus.GaugeValue, time.Since(st.LastFrame).Seconds())
+	}
+	ch <- prometheus.MustNewConstMetric(c.fps, prometheus.GaugeValue, st.FPS)
+	var streaming float64
+	if st.Streaming {
+		streaming = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.streaming, prometheus.GaugeValue, streaming)
+	queued, total := c.snapper.QueueDepth()
+	ch <- prometheus.MustNewConstMetric(c.queued, prometheus.GaugeValue, float64(queued))
+	ch <- prometheus.MustNewConstMetric(c.buffers, prometheus.GaugeValue, float64(total))
+}
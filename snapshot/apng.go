@@ -0,0 +1,149 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"time"
+)
+
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// RecordAPNG captures frames at fps frames per second until the context
+// is cancelled, then writes them to w as an animated PNG that loops forever.
+// Since the frame count must appear before the frames, the encoded frames
+// are held in memory, so this is intended for short clips. The frame
+// size is taken from the first frame.
+func (c *Snapper) RecordAPNG(ctx context.Context, w io.Writer, fps int) error {
+	if fps <= 0 {
+		return fmt.Errorf("illegal frame rate %d", fps)
+	}
+	ticker := time.NewTicker(time.Second / time.Duration(fps))
+	defer ticker.Stop()
+	var ihdr []byte
+	var frames [][]byte
+	var bounds image.Rectangle
+	for {
+		select {
+		case <-ctx.Done():
+			if len(frames) == 0 {
+				return fmt.Errorf("no frames captured")
+			}
+			return writeAPNG(w, ihdr, frames, fps)
+		case <-ticker.C:
+		}
+		f, err := c.Snap()
+		if err != nil {
+			return err
+		}
+		if len(frames) == 0 {
+			bounds = f.Bounds()
+		}
+		// Every frame must have the same size and colour type as the first.
+		img := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(img, img.Bounds(), f, f.Bounds().Min, draw.Src)
+		f.Release()
+		hdr, data, err := encodePNGFrame(img)
+		if err != nil {
+			return err
+		}
+		if ihdr == nil {
+			ihdr = hdr
+		} else if !bytes.Equal(hdr, ihdr) {
+			return fmt.Errorf("frame %d has a different PNG header", len(frames))
+		}
+		frames = append(frames, data)
+	}
+}
+
+// encodePNGFrame encodes the image as a PNG, and returns the IHDR
+// chunk data and the concatenated IDAT data.
+func encodePNGFrame(img image.Image) (ihdr, data []byte, err error) {
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := enc.Encode(&buf, img); err != nil {
+		return nil, nil, err
+	}
+	b := buf.Bytes()[len(pngSignature):]
+	for len(b) >= 12 {
+		n := binary.BigEndian.Uint32(b)
+		if int(n) > len(b)-12 {
+			return nil, nil, fmt.Errorf("corrupt PNG chunk")
+		}
+		body := b[8 : 8+n]
+		switch string(b[4:8]) {
+		case "IHDR":
+			ihdr = body
+		case "IDAT":
+			data = append(data, body...)
+		}
+		b = b[12+n:]
+	}
+	return ihdr, data, nil
+}
+
+// writeAPNG writes the frames as an animated PNG. The first frame is
+// stored as IDAT chunks, so that viewers without APNG support show it.
+func writeAPNG(w io.Writer, ihdr []byte, frames [][]byte, fps int) error {
+	_, err := io.WriteString(w, pngSignature)
+	bw := &chunkWriter{w: w, err: err}
+	bw.chunk("IHDR", ihdr)
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(frames)))
+	binary.BigEndian.PutUint32(actl[4:], 0) // Loop forever.
+	bw.chunk("acTL", actl)
+	var seq uint32
+	for i, data := range frames {
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], seq)
+		copy(fctl[4:12], ihdr[0:8]) // Width and height.
+		// The offset, disposal and blend operations are all zero.
+		binary.BigEndian.PutUint16(fctl[20:], 1)
+		binary.BigEndian.PutUint16(fctl[22:], uint16(fps))
+		bw.chunk("fcTL", fctl)
+		seq++
+		if i == 0 {
+			bw.chunk("IDAT", data)
+			continue
+		}
+		fdat := make([]byte, 4+len(data))
+		binary.BigEndian.PutUint32(fdat, seq)
+		copy(fdat[4:], data)
+		bw.chunk("fdAT", fdat)
+		seq++
+	}
+	bw.chunk("IEND", nil)
+	return bw.err
+}
+
+// chunkWriter writes PNG chunks, retaining the first error.
+type chunkWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (c *chunkWriter) chunk(name string, data []byte) {
+	if c.err != nil {
+		return
+	}
+	hdr := make([]byte, 8)
+	binary.BigEndian.PutUint32(hdr, uint32(len(data)))
+	copy(hdr[4:], name)
+	crc := crc32.NewIEEE()
+	crc.Write(hdr[4:])
+	crc.Write(data)
+	tail := make([]byte, 4)
+	binary.BigEndian.PutUint32(tail, crc.Sum32())
+	for _, b := range [][]byte{hdr, data, tail} {
+		if _, err := c.w.Write(b); err != nil {
+			c.err = err
+			return
+		}
+	}
+}
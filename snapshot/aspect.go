@@ -0,0 +1,33 @@
+package snapshot
+
+import (
+	"image"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// PixelAspect returns the pixel aspect ratio (the height / width of a
+// pixel) reported by the driver. Webcams usually have square pixels (1/1),
+// but analog capture devices do not, e.g 11/10 for NTSC.
+func (c *Snapper) PixelAspect() (num, den int, err error) {
+	cam, err := c.openCam()
+	if err != nil {
+		return 0, 0, err
+	}
+	n, d, err := cam.GetPixelAspect()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(n), int(d), nil
+}
+
+// CorrectAspect returns a copy of the frame rescaled to square pixels
+// using the pixel aspect reported by the driver. If the driver does
+// not report the pixel aspect, square pixels are assumed.
+func (c *Snapper) CorrectAspect(f frame.Frame) *image.RGBA {
+	num, den, err := c.PixelAspect()
+	if err != nil {
+		num, den = 1, 1
+	}
+	return frame.ScaleAspect(f, num, den)
+}
@@ -0,0 +1,57 @@
+package snapshot
+
+import (
+	"time"
+
+	"github.com/aamcrae/webcam"
+)
+
+// LockAutoControls enables auto white balance and auto exposure,
+// then reads and discards frames for the warmup period so that the
+// camera can converge on suitable values. The auto controls are then
+// disabled, leaving the converged white balance and exposure in place.
+// This avoids flicker from lighting changes, e.g in a timelapse.
+func (c *Snapper) LockAutoControls(warmup time.Duration) error {
+	cam, err := c.openCam()
+	if err != nil {
+		return err
+	}
+	if err := cam.SetAutoWhiteBalance(true); err != nil {
+		return err
+	}
+	expAuto := webcam.ControlID(webcam.V4L2_CID_EXPOSURE_AUTO)
+	// Most UVC cameras only support aperture priority as the auto mode.
+	if err := cam.SetControl(expAuto, webcam.V4L2_EXPOSURE_APERTURE_PRIORITY); err != nil {
+		if err := cam.SetControl(expAuto, webcam.V4L2_EXPOSURE_AUTO); err != nil {
+			return err
+		}
+	}
+	end := time.Now().Add(warmup)
+	for time.Now().Before(end) {
+		f, err := c.snap()
+		if err != nil {
+			return err
+		}
+		f.Release()
+	}
+	// Read the converged values so they can be restored once the
+	// auto controls are off, since some drivers revert to the previous
+	// manual values.
+	wbTemp := webcam.ControlID(webcam.V4L2_CID_WHITE_BALANCE_TEMPERATURE)
+	expAbs := webcam.ControlID(webcam.V4L2_CID_EXPOSURE_ABSOLUTE)
+	temp, tempErr := cam.GetControl(wbTemp)
+	exp, expErr := cam.GetControl(expAbs)
+	if err := cam.SetAutoWhiteBalance(false); err != nil {
+		return err
+	}
+	if err := cam.SetControl(expAuto, webcam.V4L2_EXPOSURE_MANUAL); err != nil {
+		return err
+	}
+	if tempErr == nil {
+		cam.SetControl(wbTemp, temp)
+	}
+	if expErr == nil {
+		cam.SetControl(expAbs, exp)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package snapshot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aamcrae/webcam"
+)
+
+func TestLockAutoControls(t *testing.T) {
+	c := NewSnapper()
+	expAuto := webcam.ControlID(webcam.V4L2_CID_EXPOSURE_AUTO)
+	expAbs := webcam.ControlID(webcam.V4L2_CID_EXPOSURE_ABSOLUTE)
+	wbTemp := webcam.ControlID(webcam.V4L2_CID_WHITE_BALANCE_TEMPERATURE)
+	d := openFake(t, c, func(cam *fakeCamera) {
+		cam.controls[expAuto] = webcam.V4L2_EXPOSURE_MANUAL
+		cam.controls[expAbs] = 200
+		cam.controls[wbTemp] = 450
+	})
+	cam := d.last()
+	before := len(cam.setLog())
+	cam.mu.Lock()
+	seq := cam.seq
+	cam.mu.Unlock()
+	if err := c.LockAutoControls(10 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	// The auto controls are enabled for the warmup, then disabled and
+	// the converged values restored.
+	want := []fakeSet{
+		{awb, 1},
+		{expAuto, webcam.V4L2_EXPOSURE_APERTURE_PRIORITY},
+		{awb, 0},
+		{expAuto, webcam.V4L2_EXPOSURE_MANUAL},
+		{wbTemp, 450},
+		{expAbs, 200},
+	}
+	if got := cam.setLog()[before:]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("controls set: got %v, want %v", got, want)
+	}
+	cam.mu.Lock()
+	defer cam.mu.Unlock()
+	if cam.seq == seq {
+		t.Fatal("no frames read during the warmup")
+	}
+}
@@ -0,0 +1,38 @@
+package snapshot
+
+import (
+	"math"
+)
+
+const (
+	bufferLatency = 0.25     // Seconds of frames to buffer.
+	bufferMemory  = 64 << 20 // Maximum memory to use for buffers.
+	minBuffers    = 2
+	maxBuffers    = 32
+	assumedFPS    = 30 // Used if the driver does not report the frame rate.
+)
+
+// SuggestBuffers returns a buffer count that holds about 250ms of frames
+// at the given frame rate, limited so that the buffers of the given size
+// (in bytes) do not use an excessive amount of memory.
+func SuggestBuffers(size int, fps float64) uint32 {
+	n := int(math.Ceil(fps * bufferLatency))
+	if size > 0 && n*size > bufferMemory {
+		n = bufferMemory / size
+	}
+	if n < minBuffers {
+		n = minBuffers
+	} else if n > maxBuffers {
+		n = maxBuffers
+	}
+	return uint32(n)
+}
+
+// autoBuffers sets Buffers from the negotiated frame size and frame rate.
+func (c *Snapper) autoBuffers() {
+	fps, err := c.cam.GetFramerate()
+	if err != nil || fps <= 0 {
+		fps = assumedFPS
+	}
+	c.Buffers = SuggestBuffers(c.size, fps)
+}
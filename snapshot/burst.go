@@ -0,0 +1,40 @@
+package snapshot
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// Burst captures n consecutive frames without dropping any, and returns
+// them along with their capture timestamps so that the actual frame
+// interval can be checked.
+// While the burst is in progress, the capture loop queues frames rather
+// than dropping them. The frames are held by the caller until released,
+// so n must be less than the number of buffers.
+// If an error occurs, any frames already captured are released.
+func (c *Snapper) Burst(n int) ([]frame.Frame, []time.Time, error) {
+	if n >= int(c.Buffers) {
+		return nil, nil, fmt.Errorf("burst of %d frames needs more than %d buffers", n, c.Buffers)
+	}
+	prev := atomic.SwapInt32(&c.queued, 1)
+	defer atomic.StoreInt32(&c.queued, prev)
+	// Discard any stale frame waiting in the stream.
+	c.discardStale()
+	frames := make([]frame.Frame, 0, n)
+	times := make([]time.Time, 0, n)
+	for len(frames) < n {
+		f, err := c.snap()
+		if err != nil {
+			for _, f := range frames {
+				f.Release()
+			}
+			return nil, nil, err
+		}
+		frames = append(frames, f)
+		times = append(times, frame.TimestampOf(f))
+	}
+	return frames, times, nil
+}
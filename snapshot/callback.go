@@ -0,0 +1,40 @@
+package snapshot
+
+import (
+	"github.com/aamcrae/webcam/frame"
+)
+
+// OnFrame registers fn to be called from the capture goroutine for every
+// frame captured, before the frame is made available to Snap. Multiple
+// callbacks may be registered, and are called in the order registered.
+//
+// The frame is released when the callbacks return, so a callback must
+// copy any data it wishes to keep (e.g using Snapper.Copy), and must not
+// release the frame itself. Frames are still delivered to Snap, so the
+// callbacks do not need to read from the stream. Callbacks delay the
+// capture of the following frames, so they should be fast.
+func (c *Snapper) OnFrame(fn func(frame.Frame)) {
+	c.callbackMu.Lock()
+	defer c.callbackMu.Unlock()
+	c.callbacks = append(c.callbacks, fn)
+}
+
+// notify calls the registered callbacks with the frame.
+func (c *Snapper) notify(s snap) {
+	c.callbackMu.Lock()
+	callbacks := c.callbacks
+	c.callbackMu.Unlock()
+	if len(callbacks) == 0 {
+		return
+	}
+	// The buffer remains owned by the capture goroutine, so
+	// releasing this frame does not return it to the camera.
+	f, err := c.wrapFrame(s, nil)
+	if err != nil {
+		return
+	}
+	for _, fn := range callbacks {
+		fn(f)
+	}
+	f.Release()
+}
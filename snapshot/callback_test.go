@@ -0,0 +1,56 @@
+package snapshot
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+func TestOnFrame(t *testing.T) {
+	c := NewSnapper()
+	var mu sync.Mutex
+	var first, second []uint32
+	enough := make(chan struct{})
+	c.OnFrame(func(f frame.Frame) {
+		mu.Lock()
+		defer mu.Unlock()
+		first = append(first, frame.SequenceOf(f))
+	})
+	c.OnFrame(func(f frame.Frame) {
+		mu.Lock()
+		defer mu.Unlock()
+		seq := frame.SequenceOf(f)
+		if len(first) == 0 || first[len(first)-1] != seq {
+			t.Errorf("frame %d passed to the second callback before the first", seq)
+		}
+		second = append(second, seq)
+		if len(second) == 10 {
+			close(enough)
+		}
+	})
+	d := openFake(t, c, nil)
+	// Frames are passed to the callbacks without being read by Snap.
+	select {
+	case <-enough:
+	case <-time.After(time.Second):
+		t.Fatal("callbacks not called")
+	}
+	cam := d.last()
+	c.Close()
+	cam.mu.Lock()
+	captured := cam.seq
+	cam.mu.Unlock()
+	mu.Lock()
+	defer mu.Unlock()
+	// Each frame captured is passed to each callback once, in order.
+	if len(first) != int(captured) || len(second) != int(captured) {
+		t.Fatalf("callbacks called %d and %d times for %d frames", len(first), len(second), captured)
+	}
+	for i, seq := range first {
+		if seq != uint32(i+1) {
+			t.Fatalf("callback %d got frame %d", i, seq)
+		}
+	}
+}
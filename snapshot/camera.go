@@ -0,0 +1,127 @@
+package snapshot
+
+import (
+	"image"
+	"sync"
+
+	"github.com/aamcrae/webcam"
+)
+
+// camera is the set of webcam methods used by Snapper, so that
+// an alternative implementation (e.g for testing) can be substituted.
+type camera interface {
+	GetSupportedFormats() map[webcam.PixelFormat]string
+	GetSupportedFrameSizes(webcam.PixelFormat) []webcam.FrameSize
+	GetFrameRates(webcam.PixelFormat, uint32, uint32) []float64
+	GetCapability() (webcam.Capability, error)
+	GetControlMenu(webcam.ControlID) (map[int32]string, error)
+	GetImageFormat() (webcam.PixelFormat, uint32, uint32, error)
+	SetImageFormat(webcam.PixelFormat, uint32, uint32) (webcam.PixelFormat, uint32, uint32, uint32, uint32, error)
+	SetField(uint32)
+	SetColorimetry(webcam.Colorimetry)
+	GetColorimetry() webcam.Colorimetry
+	GetInputs() ([]webcam.Input, error)
+	GetInput() (uint32, error)
+	SetInput(uint32) error
+	GetStandards() ([]webcam.Standard, error)
+	GetStandard() (uint64, error)
+	SetStandard(uint64) error
+	SetBufferCount(uint32) error
+	GetBufferCount() uint32
+	GetFramerate() (float64, error)
+	GetPixelAspect() (uint32, uint32, error)
+	SetFramerate(float64) error
+	SetIOMethod(uint32) error
+	SetAutoWhiteBalance(bool) error
+	GetControls() map[webcam.ControlID]webcam.Control
+	GetControl(webcam.ControlID) (int32, error)
+	SetControl(webcam.ControlID, int32) error
+	GetRectControl(webcam.ControlID) (image.Rectangle, error)
+	SetRectControl(webcam.ControlID, image.Rectangle) (image.Rectangle, error)
+	GetExtControls([]webcam.ControlID) (map[webcam.ControlID]int32, error)
+	SetExtControls(map[webcam.ControlID]int32) error
+	StartStreaming() error
+	StopStreaming() error
+	WaitForFrame(uint32) error
+	GetFrameInfo() ([]byte, uint32, webcam.FrameInfo, error)
+	ReleaseFrame(uint32) error
+	Close() error
+}
+
+// openCamera opens the device. It may be replaced to return
+// an alternative camera implementation.
+var openCamera = func(device string) (camera, error) {
+	cam, err := webcam.Open(device)
+	if err != nil {
+		return nil, err
+	}
+	return cam, nil
+}
+
+// streamCam is a camera opened by Snapper. It counts the buffers
+// dequeued from the camera, so that when the camera is replaced
+// (e.g by reconnect) it can be kept open until the frames still held
+// are released, since stopping the camera unmaps their buffers.
+type streamCam struct {
+	camera
+	mu       sync.Mutex
+	dequeued int  // Buffers dequeued and not yet released.
+	retired  bool // Stop the camera once all buffers are released.
+	stopped  bool
+}
+
+// GetFrameInfo dequeues a frame, counting the buffer as dequeued.
+func (s *streamCam) GetFrameInfo() ([]byte, uint32, webcam.FrameInfo, error) {
+	b, index, info, err := s.camera.GetFrameInfo()
+	if err == nil {
+		s.mu.Lock()
+		s.dequeued++
+		s.mu.Unlock()
+	}
+	return b, index, info, err
+}
+
+// ReleaseFrame returns the buffer to the camera, or if the camera is
+// retired stops the camera once the last buffer is released.
+func (s *streamCam) ReleaseFrame(index uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dequeued--
+	if s.stopped {
+		return nil
+	}
+	if !s.retired {
+		return s.camera.ReleaseFrame(index)
+	}
+	if s.dequeued == 0 {
+		s.stop()
+	}
+	return nil
+}
+
+// retire stops the camera once the frames dequeued from it are released.
+func (s *streamCam) retire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retired = true
+	if s.dequeued == 0 {
+		s.stop()
+	}
+}
+
+// shutdown stops and closes the camera, invalidating any frames still held.
+func (s *streamCam) shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop()
+}
+
+// stop stops streaming and closes the camera, unless it is already closed.
+// It is called with mu held.
+func (s *streamCam) stop() {
+	if !s.stopped {
+		s.stopped = true
+		s.camera.StopStreaming()
+		s.camera.Close()
+	}
+}
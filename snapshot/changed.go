@@ -0,0 +1,44 @@
+package snapshot
+
+import (
+	"image"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// SnapChanged returns one frame from the camera, and a flag indicating
+// whether the frame differs from the previous frame returned by SnapChanged.
+// The difference is the mean absolute difference of the luminance of
+// each pixel (0-255), and the frame is considered changed if this
+// is greater than threshold. The first frame is always considered changed.
+// The frame is returned in either case, and must be released by the caller.
+func (c *Snapper) SnapChanged(threshold float64) (frame.Frame, bool, error) {
+	f, err := c.Snap()
+	if err != nil {
+		return nil, false, err
+	}
+	g := frame.LuminanceImage(f)
+	c.prevMu.Lock()
+	defer c.prevMu.Unlock()
+	changed := c.prev == nil || !c.prev.Bounds().Eq(g.Bounds()) || meanAbsDiff(c.prev, g) > threshold
+	c.prev = g
+	return f, changed, nil
+}
+
+// meanAbsDiff returns the mean absolute difference between two
+// images of the same size.
+func meanAbsDiff(a, b *image.Gray) float64 {
+	if len(a.Pix) == 0 {
+		return 0
+	}
+	var sum uint64
+	for i, p := range a.Pix {
+		q := b.Pix[i]
+		if p > q {
+			sum += uint64(p - q)
+		} else {
+			sum += uint64(q - p)
+		}
+	}
+	return float64(sum) / float64(len(a.Pix))
+}
@@ -0,0 +1,151 @@
+package snapshot
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/aamcrae/webcam"
+)
+
+// ErrControlUnsupported is returned when the camera does not have the control.
+var ErrControlUnsupported = errors.New("control not supported by camera")
+
+// getNamed returns the value of the control, if the camera supports it.
+func (c *Snapper) getNamed(id uint32) (int32, error) {
+	cam, err := c.openCam()
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := cam.GetControls()[webcam.ControlID(id)]; !ok {
+		return 0, ErrControlUnsupported
+	}
+	return cam.GetControl(webcam.ControlID(id))
+}
+
+// setNamed sets the control, if the camera supports it.
+func (c *Snapper) setNamed(id uint32, value int32) error {
+	cam, err := c.openCam()
+	if err != nil {
+		return err
+	}
+	if _, ok := cam.GetControls()[webcam.ControlID(id)]; !ok {
+		return ErrControlUnsupported
+	}
+	return cam.SetControl(webcam.ControlID(id), value)
+}
+
+// Sharpness returns the current sharpness setting.
+func (c *Snapper) Sharpness() (int32, error) {
+	return c.getNamed(webcam.V4L2_CID_SHARPNESS)
+}
+
+// SetSharpness sets the sharpness.
+func (c *Snapper) SetSharpness(value int32) error {
+	return c.setNamed(webcam.V4L2_CID_SHARPNESS, value)
+}
+
+// BacklightCompensation returns the current backlight compensation setting.
+func (c *Snapper) BacklightCompensation() (int32, error) {
+	return c.getNamed(webcam.V4L2_CID_BACKLIGHT_COMPENSATION)
+}
+
+// SetBacklightCompensation sets the backlight compensation.
+func (c *Snapper) SetBacklightCompensation(value int32) error {
+	return c.setNamed(webcam.V4L2_CID_BACKLIGHT_COMPENSATION, value)
+}
+
+// Gamma returns the current gamma setting.
+func (c *Snapper) Gamma() (int32, error) {
+	return c.getNamed(webcam.V4L2_CID_GAMMA)
+}
+
+// SetGamma sets the gamma.
+func (c *Snapper) SetGamma(value int32) error {
+	return c.setNamed(webcam.V4L2_CID_GAMMA, value)
+}
+
+// Zoom returns the current absolute zoom setting.
+func (c *Snapper) Zoom() (int32, error) {
+	return c.getNamed(webcam.V4L2_CID_ZOOM_ABSOLUTE)
+}
+
+// SetZoom sets the absolute zoom of cameras with a motorized or digital
+// zoom. The range is driver specific (see GetControls for the limits).
+func (c *Snapper) SetZoom(level int32) error {
+	return c.setNamed(webcam.V4L2_CID_ZOOM_ABSOLUTE, level)
+}
+
+// Pan returns the current absolute pan setting, in arc seconds.
+func (c *Snapper) Pan() (int32, error) {
+	return c.getNamed(webcam.V4L2_CID_PAN_ABSOLUTE)
+}
+
+// SetPan sets the absolute pan of PTZ cameras, in arc seconds,
+// with positive values turning the camera to the right.
+func (c *Snapper) SetPan(value int32) error {
+	return c.setNamed(webcam.V4L2_CID_PAN_ABSOLUTE, value)
+}
+
+// Tilt returns the current absolute tilt setting, in arc seconds.
+func (c *Snapper) Tilt() (int32, error) {
+	return c.getNamed(webcam.V4L2_CID_TILT_ABSOLUTE)
+}
+
+// SetTilt sets the absolute tilt of PTZ cameras, in arc seconds,
+// with positive values turning the camera upwards.
+func (c *Snapper) SetTilt(value int32) error {
+	return c.setNamed(webcam.V4L2_CID_TILT_ABSOLUTE, value)
+}
+
+// GetControls returns the current values of several controls,
+// read in a single request.
+func (c *Snapper) GetControls(ids []webcam.ControlID) (map[webcam.ControlID]int32, error) {
+	cam, err := c.openCam()
+	if err != nil {
+		return nil, err
+	}
+	return cam.GetExtControls(ids)
+}
+
+// SetControls sets several controls in a single request, so that
+// they change together (or not at all if any value is invalid).
+func (c *Snapper) SetControls(controls map[webcam.ControlID]int32) error {
+	cam, err := c.openCam()
+	if err != nil {
+		return err
+	}
+	return cam.SetExtControls(controls)
+}
+
+// Temperature returns the sensor temperature, for cameras that report it.
+// There is no standard V4L2 control for temperature, so the first
+// control with "temperature" in its name (other than the white balance
+// temperature) is read. The value is in the units reported by the
+// driver, which is usually degrees Celsius.
+// ErrControlUnsupported is returned if there is no such control.
+func (c *Snapper) Temperature() (float64, error) {
+	cam, err := c.openCam()
+	if err != nil {
+		return 0, err
+	}
+	controls := cam.GetControls()
+	// Search in ID order so the same control is always chosen.
+	var ids []webcam.ControlID
+	for id, ctrl := range controls {
+		name := strings.ToLower(ctrl.Name)
+		if id != webcam.ControlID(webcam.V4L2_CID_WHITE_BALANCE_TEMPERATURE) &&
+			strings.Contains(name, "temperature") && !strings.Contains(name, "white balance") {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, ErrControlUnsupported
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	v, err := cam.GetControl(ids[0])
+	if err != nil {
+		return 0, err
+	}
+	return float64(v), nil
+}
@@ -0,0 +1,99 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// ErrSlabFull is returned when all the slots in a SlabAllocator are in use.
+var ErrSlabFull = errors.New("no free slab slots")
+
+// Copy returns a copy of the frame that does not hold a camera buffer,
+// so that it can be kept for as long as required. The original frame
+// is not released.
+func (c *Snapper) Copy(f frame.Frame) (frame.Frame, error) {
+	raw := frame.RawOf(f)
+	b := make([]byte, len(raw))
+	copy(b, raw)
+	return c.wrapCopy(f, b, nil)
+}
+
+// wrapCopy wraps a copy of the raw frame, keeping the frame metadata.
+// The copy is decoded with the framer that created the frame, which may
+// differ from the current framer after SetFramer, and is given the same
+// orientation.
+func (c *Snapper) wrapCopy(f frame.Frame, b []byte, rel func()) (frame.Frame, error) {
+	framer := frame.FramerOf(f)
+	if framer == nil {
+		framer, _ = c.getFramer()
+	}
+	n, err := framer(b, rel)
+	if err != nil {
+		return nil, err
+	}
+	frame.WithTimestamp(frame.TimestampOf(f))(n)
+	frame.WithSequence(frame.SequenceOf(f))(n)
+	frame.WithFramer(framer)(n)
+	if tag := frame.TagOf(f); tag != nil {
+		frame.WithTag(tag)(n)
+	}
+	return frame.Orient(n, frame.OrientationOf(f)), nil
+}
+
+// SlabAllocator copies frames into slots of a single preallocated slab,
+// giving predictable memory use and no per-frame allocation for the
+// frame data, e.g when buffering a fixed window of frames.
+// Slots are reused in round-robin order once released.
+// A copied frame refers directly to its slot, so the frame must not be
+// used after it is released, as the slot may then be overwritten.
+type SlabAllocator struct {
+	mu       sync.Mutex
+	slab     []byte
+	slotSize int
+	free     []int // Free slot indices, oldest first.
+}
+
+// NewSlabAllocator creates a slab with the given number of slots,
+// each of which can hold a frame of up to slotSize bytes.
+func NewSlabAllocator(slotSize, slots int) *SlabAllocator {
+	a := &SlabAllocator{slab: make([]byte, slotSize*slots), slotSize: slotSize}
+	for i := 0; i < slots; i++ {
+		a.free = append(a.free, i)
+	}
+	return a
+}
+
+// Copy copies the frame into a free slot, decoding it as Snapper.Copy does.
+// ErrSlabFull is returned if no slot is free.
+// The original frame is not released.
+func (a *SlabAllocator) Copy(c *Snapper, f frame.Frame) (frame.Frame, error) {
+	raw := frame.RawOf(f)
+	if len(raw) > a.slotSize {
+		return nil, fmt.Errorf("frame size %d exceeds slot size %d", len(raw), a.slotSize)
+	}
+	a.mu.Lock()
+	if len(a.free) == 0 {
+		a.mu.Unlock()
+		return nil, ErrSlabFull
+	}
+	slot := a.free[0]
+	a.free = a.free[1:]
+	a.mu.Unlock()
+	b := a.slab[slot*a.slotSize:][:len(raw)]
+	copy(b, raw)
+	return c.wrapCopy(f, b, func() {
+		a.mu.Lock()
+		a.free = append(a.free, slot)
+		a.mu.Unlock()
+	})
+}
+
+// Free returns the number of unused slots.
+func (a *SlabAllocator) Free() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.free)
+}
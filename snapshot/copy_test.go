@@ -0,0 +1,111 @@
+package snapshot
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aamcrae/webcam"
+	"github.com/aamcrae/webcam/frame"
+)
+
+// sameImage fails the test if the frames differ in bounds or pixels.
+func sameImage(t *testing.T, got, want frame.Frame) {
+	t.Helper()
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("Bounds: got %v, want %v", got.Bounds(), want.Bounds())
+	}
+	b := want.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if g, w := got.At(x, y), want.At(x, y); g != w {
+				t.Fatalf("At(%d, %d): got %v, want %v", x, y, g, w)
+			}
+		}
+	}
+}
+
+func TestCopyAutoOrient(t *testing.T) {
+	c := NewSnapper()
+	c.AutoOrient = true
+	openFake(t, c, func(cam *fakeCamera) {
+		cam.controls[webcam.ControlID(webcam.V4L2_CID_CAMERA_SENSOR_ROTATION)] = 90
+	})
+	f, err := c.Snap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Release()
+	cp, err := c.Copy(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cp.Release()
+	if o := frame.OrientationOf(cp); o != frame.OrientRotate270 {
+		t.Fatalf("OrientationOf: got %d, want %d", o, frame.OrientRotate270)
+	}
+	sameImage(t, cp, f)
+}
+
+func TestCopyAfterSetFramer(t *testing.T) {
+	c := NewSnapper()
+	useFake(t, func() *fakeCamera { return newFakeCamera("RGB3", 4, 2) })
+	if err := c.Open("/dev/fake", "RGB3", 4, 2); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	f, err := c.Snap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Release()
+	// Make the red and blue components differ, so that decoding the
+	// copy as BGR3 would change the first pixel.
+	frame.RawOf(f)[0] = 0xff
+	if err := c.SetFramer("BGR3"); err != nil {
+		t.Fatal(err)
+	}
+	cp, err := c.Copy(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cp.Release()
+	sameImage(t, cp, f)
+}
+
+func TestSlabAllocatorReuse(t *testing.T) {
+	c := NewSnapper()
+	openFake(t, c, nil)
+	a := NewSlabAllocator(16, 1)
+	f, err := c.Snap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp, err := a.Copy(c, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameImage(t, cp, f)
+	if _, err := a.Copy(c, f); !errors.Is(err, ErrSlabFull) {
+		t.Fatalf("Copy with no free slot: got %v, want ErrSlabFull", err)
+	}
+	f.Release()
+	cp.Release()
+	if a.Free() != 1 {
+		t.Fatalf("Free: got %d after release, want 1", a.Free())
+	}
+	// The slot is reused for a later frame, which has different data.
+	f, err = c.Snap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Release()
+	cp, err = a.Copy(c, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cp.Release()
+	sameImage(t, cp, f)
+	if frame.SequenceOf(cp) != frame.SequenceOf(f) {
+		t.Fatalf("Sequence: got %d, want %d", frame.SequenceOf(cp), frame.SequenceOf(f))
+	}
+}
@@ -0,0 +1,34 @@
+package snapshot
+
+import (
+	"context"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// Each reads frames in a loop and calls fn with each frame.
+// The frame is released once fn returns, so fn must copy any data
+// it wishes to keep. The loop stops when fn returns an error, or the
+// context is cancelled, and the error is returned.
+func (c *Snapper) Each(ctx context.Context, fn func(frame.Frame) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		f, err := c.Snap()
+		if err != nil {
+			return err
+		}
+		if err := callAndRelease(f, fn); err != nil {
+			return err
+		}
+	}
+}
+
+// callAndRelease calls fn, and releases the frame even if fn panics.
+func callAndRelease(f frame.Frame, fn func(frame.Frame) error) error {
+	defer f.Release()
+	return fn(f)
+}
@@ -0,0 +1,103 @@
+package snapshot
+
+import (
+	"image"
+
+	"github.com/aamcrae/webcam"
+	"github.com/aamcrae/webcam/frame"
+)
+
+// Fraction of the required exposure change applied in each step,
+// to avoid oscillation.
+const exposureDamping = 0.5
+
+// Mean luminance within this distance of the target needs no adjustment.
+const exposureTolerance = 4.0
+
+// SetMeteringRegion sets the region of the frame used by AutoExposeStep
+// to measure brightness, giving spot or centre weighted metering.
+// An empty rectangle meters the whole frame.
+func (c *Snapper) SetMeteringRegion(r image.Rectangle) {
+	c.stateMu.Lock()
+	c.metering = r
+	c.stateMu.Unlock()
+}
+
+// AutoExposeStep is one iteration of a software auto-exposure loop.
+// It measures the mean luminance (0-255) of the frame within the metering
+// region and adjusts the absolute exposure control towards the target
+// luminance, returning the new exposure value.
+// The camera's own auto exposure should be set to manual mode.
+// AutoExposeStep is typically called for each frame read.
+func (c *Snapper) AutoExposeStep(f frame.Frame, target float64) (int32, error) {
+	cam, err := c.openCam()
+	if err != nil {
+		return 0, err
+	}
+	id := webcam.ControlID(webcam.V4L2_CID_EXPOSURE_ABSOLUTE)
+	ctrl, ok := cam.GetControls()[id]
+	if !ok {
+		return 0, ErrControlUnsupported
+	}
+	cur, err := cam.GetControl(id)
+	if err != nil {
+		return 0, err
+	}
+	r := f.Bounds()
+	c.stateMu.Lock()
+	metering := c.metering
+	c.stateMu.Unlock()
+	if !metering.Empty() {
+		r = r.Intersect(metering)
+	}
+	mean := meanLuminance(f, r)
+	if mean-target < exposureTolerance && target-mean < exposureTolerance {
+		return cur, nil
+	}
+	if mean < 1 {
+		mean = 1
+	}
+	want := float64(cur) * target / mean
+	next := int32(float64(cur) + (want-float64(cur))*exposureDamping)
+	if next == cur {
+		if mean < target {
+			next++
+		} else {
+			next--
+		}
+	}
+	if next < ctrl.Min {
+		next = ctrl.Min
+	} else if next > ctrl.Max {
+		next = ctrl.Max
+	}
+	if next != cur {
+		if err := cam.SetControl(id, next); err != nil {
+			return cur, err
+		}
+	}
+	return next, nil
+}
+
+// meanLuminance returns the mean luminance of the image within r.
+func meanLuminance(img image.Image, r image.Rectangle) float64 {
+	if r.Empty() {
+		return 0
+	}
+	var sum uint64
+	if l, ok := img.(frame.Luminancer); ok {
+		// Avoid checking for the fast path at every pixel.
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				sum += uint64(l.LuminanceAt(x, y))
+			}
+		}
+	} else {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				sum += uint64(frame.LuminanceAt(img, x, y))
+			}
+		}
+	}
+	return float64(sum) / float64(r.Dx()*r.Dy())
+}
@@ -0,0 +1,77 @@
+package snapshot
+
+import (
+	"image"
+	"testing"
+
+	"github.com/aamcrae/webcam"
+	"github.com/aamcrae/webcam/frame"
+)
+
+// splitFrame returns a 4x2 YUYV frame that is bright on the left
+// and dark on the right.
+func splitFrame(t *testing.T) frame.Frame {
+	t.Helper()
+	framer, err := frame.GetFramer("YUYV", 4, 2, 8, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := []byte{200, 128, 200, 128, 20, 128, 20, 128}
+	f, err := framer(append(append([]byte(nil), row...), row...), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestMeteringRegion(t *testing.T) {
+	c := NewSnapper()
+	id := webcam.ControlID(webcam.V4L2_CID_EXPOSURE_ABSOLUTE)
+	openFake(t, c, func(cam *fakeCamera) {
+		cam.controls[id] = 100
+	})
+	f := splitFrame(t)
+	defer f.Release()
+	for _, tc := range []struct {
+		region image.Rectangle
+		darker bool
+	}{
+		{image.Rect(0, 0, 2, 2), true},
+		{image.Rect(2, 0, 4, 2), false},
+	} {
+		if err := c.SetControl(id, 100); err != nil {
+			t.Fatal(err)
+		}
+		c.SetMeteringRegion(tc.region)
+		exp, err := c.AutoExposeStep(f, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.darker != (exp < 100) {
+			t.Errorf("metering %v: exposure changed from 100 to %d", tc.region, exp)
+		}
+	}
+}
+
+func TestSetMeteringRegionWhileExposing(t *testing.T) {
+	c := NewSnapper()
+	id := webcam.ControlID(webcam.V4L2_CID_EXPOSURE_ABSOLUTE)
+	openFake(t, c, func(cam *fakeCamera) {
+		cam.controls[id] = 100
+	})
+	f := splitFrame(t)
+	defer f.Release()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			c.SetMeteringRegion(image.Rect(i%4, 0, 4, 2))
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		if _, err := c.AutoExposeStep(f, 100); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+}
@@ -0,0 +1,757 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aamcrae/webcam"
+	"github.com/aamcrae/webcam/frame"
+)
+
+// fakeStep is a scripted result of reading a frame from a fakeCamera.
+// If wait is set it is returned by WaitForFrame, otherwise get is
+// returned by the following GetFrameInfo.
+type fakeStep struct {
+	wait error
+	get  error
+}
+
+// timeoutStep is a step where no frame arrives within the timeout.
+var timeoutStep = fakeStep{wait: &webcam.Timeout{}}
+
+// fakeSet records a control set on the fake camera.
+type fakeSet struct {
+	id webcam.ControlID
+	v  int32
+}
+
+// awb is the pseudo control recording SetAutoWhiteBalance.
+const awb = webcam.ControlID(0)
+
+// fakeCamera is an in-memory camera that produces frames of a fixed
+// format, so that Snapper can be tested without hardware. The results of
+// reading frames can be scripted, after which frames are produced every
+// interval until the camera stalls (if stall is set) or is closed.
+// A frame is only produced if a buffer is queued, as with a driver.
+type fakeCamera struct {
+	format   frame.FourCC
+	width    int
+	height   int
+	stride   int
+	size     int
+	interval time.Duration // Time taken by WaitForFrame to produce a frame.
+	// If set, once the script is done WaitForFrame blocks for the
+	// whole timeout (or until the camera is closed) and times out.
+	stall bool
+	// If set, once the script is done WaitForFrame ignores the timeout,
+	// as some drivers do, and blocks until streaming stops.
+	hang bool
+	// Other formats offered by the camera, selected with SetImageFormat.
+	formats []frame.FourCC
+	// If set, SetImageFormat selects this format instead of the one asked for.
+	substitute frame.FourCC
+	// If non-zero, SetFramerate clamps the frame rate to this.
+	maxRate float64
+	// Quantization reported by GetColorimetry. Frames are full range
+	// by default, so that pixels have the values written.
+	quantization uint32
+
+	mu        sync.Mutex
+	cond      *sync.Cond // Signalled when a buffer is queued or the camera closed.
+	script    []fakeStep
+	step      fakeStep // Step being read.
+	waits     []uint32 // Timeouts passed to WaitForFrame.
+	buffers   [][]byte
+	held      []bool // Buffers dequeued and not yet released.
+	seq       uint32
+	fps       float64 // Frame rate reported by GetFramerate.
+	field     uint32  // Field mode set by SetField.
+	streaming bool
+	closed    bool
+	controls  map[webcam.ControlID]int32
+	sets      []fakeSet // Controls set, in order.
+	bad       []string  // Misuse of the camera, reported by check.
+}
+
+// newFakeCamera returns a camera producing frames of the format and size.
+// YUYV, GREY, RGB3, YU12, YV12 and MJPG are supported.
+func newFakeCamera(format frame.FourCC, w, h int) *fakeCamera {
+	f := &fakeCamera{format: format, width: w, height: h, interval: time.Millisecond, quantization: webcam.V4L2_QUANTIZATION_FULL_RANGE, controls: map[webcam.ControlID]int32{}}
+	f.cond = sync.NewCond(&f.mu)
+	f.fps = 30
+	f.setLayout(w, h)
+	return f
+}
+
+// setLayout sets the stride and size of frames of the size.
+func (f *fakeCamera) setLayout(w, h int) {
+	f.width, f.height = w, h
+	switch f.format {
+	case "YUYV":
+		f.stride = w * 2
+	case "RGB3":
+		f.stride = w * 3
+	case "MJPG":
+		f.stride = 0
+	default:
+		f.stride = w
+	}
+	f.size, _ = frame.FrameSizeBytes(f.format, w, h, f.stride)
+	if f.format == "MJPG" {
+		// Room for the compressed frame.
+		f.size = w*h*2 + 4096
+	}
+}
+
+// run appends steps to the script.
+func (f *fakeCamera) run(steps ...fakeStep) {
+	f.mu.Lock()
+	f.script = append(f.script, steps...)
+	f.mu.Unlock()
+}
+
+// misuse records a misuse of the camera. It is called with mu held.
+func (f *fakeCamera) misuse(s string) {
+	f.bad = append(f.bad, s)
+}
+
+// check fails the test if the camera has been misused.
+func (f *fakeCamera) check(t testing.TB) {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.bad {
+		t.Error(s)
+	}
+}
+
+// dequeued returns the number of buffers held by Snapper.
+func (f *fakeCamera) dequeued() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, h := range f.held {
+		if h {
+			n++
+		}
+	}
+	return n
+}
+
+// timeouts returns the timeouts passed to WaitForFrame.
+func (f *fakeCamera) timeouts() []uint32 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]uint32(nil), f.waits...)
+}
+
+func (f *fakeCamera) isClosed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+func (f *fakeCamera) pixelFormat() webcam.PixelFormat {
+	pf, _ := frame.FourCCToPixelFormat(f.format)
+	return pf
+}
+
+func (f *fakeCamera) GetSupportedFormats() map[webcam.PixelFormat]string {
+	m := map[webcam.PixelFormat]string{f.pixelFormat(): string(f.format)}
+	for _, format := range f.formats {
+		pf, _ := frame.FourCCToPixelFormat(format)
+		m[pf] = string(format)
+	}
+	return m
+}
+
+// offers returns the offered format with the pixel format, if any.
+func (f *fakeCamera) offers(pf webcam.PixelFormat) (frame.FourCC, bool) {
+	format := frame.PixelFormatToFourCC(pf)
+	if format == f.format {
+		return format, true
+	}
+	for _, other := range f.formats {
+		if other == format {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+func (f *fakeCamera) GetSupportedFrameSizes(pf webcam.PixelFormat) []webcam.FrameSize {
+	if _, ok := f.offers(pf); !ok {
+		return nil
+	}
+	w, h := uint32(f.width), uint32(f.height)
+	return []webcam.FrameSize{{MinWidth: w, MaxWidth: w, MinHeight: h, MaxHeight: h}}
+}
+
+func (f *fakeCamera) GetFrameRates(webcam.PixelFormat, uint32, uint32) []float64 {
+	return []float64{30}
+}
+
+func (f *fakeCamera) GetCapability() (webcam.Capability, error) {
+	return webcam.Capability{Driver: "fake", Card: "Fake camera", BusInfo: "fake:0"}, nil
+}
+
+func (f *fakeCamera) GetControlMenu(webcam.ControlID) (map[int32]string, error) {
+	return nil, ErrControlUnsupported
+}
+
+func (f *fakeCamera) GetImageFormat() (webcam.PixelFormat, uint32, uint32, error) {
+	return f.pixelFormat(), uint32(f.width), uint32(f.height), nil
+}
+
+func (f *fakeCamera) SetImageFormat(pf webcam.PixelFormat, w, h uint32) (webcam.PixelFormat, uint32, uint32, uint32, uint32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	format, ok := f.offers(pf)
+	if !ok {
+		return 0, 0, 0, 0, 0, syscall.EINVAL
+	}
+	if f.substitute != "" {
+		format = f.substitute
+		pf, _ = frame.FourCCToPixelFormat(format)
+	}
+	if format != f.format {
+		// The previous format is still offered.
+		f.formats = append(f.formats, f.format)
+		f.format = format
+	}
+	f.setLayout(int(w), int(h))
+	return pf, w, h, uint32(f.stride), uint32(f.size), nil
+}
+
+func (f *fakeCamera) SetField(field uint32) {
+	f.mu.Lock()
+	f.field = field
+	f.mu.Unlock()
+}
+
+func (f *fakeCamera) SetColorimetry(webcam.Colorimetry) {}
+
+func (f *fakeCamera) GetColorimetry() webcam.Colorimetry {
+	return webcam.Colorimetry{Quantization: f.quantization}
+}
+
+func (f *fakeCamera) GetInputs() ([]webcam.Input, error) {
+	return []webcam.Input{{Name: "Camera 1", Type: webcam.V4L2_INPUT_TYPE_CAMERA}}, nil
+}
+
+func (f *fakeCamera) GetInput() (uint32, error) { return 0, nil }
+
+func (f *fakeCamera) SetInput(i uint32) error {
+	if i != 0 {
+		return syscall.EINVAL
+	}
+	return nil
+}
+
+func (f *fakeCamera) GetStandards() ([]webcam.Standard, error) { return nil, syscall.ENOTTY }
+func (f *fakeCamera) GetStandard() (uint64, error)             { return 0, syscall.ENOTTY }
+func (f *fakeCamera) SetStandard(uint64) error                 { return syscall.ENOTTY }
+
+func (f *fakeCamera) SetBufferCount(n uint32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.streaming {
+		return syscall.EBUSY
+	}
+	f.buffers = make([][]byte, n)
+	f.held = make([]bool, n)
+	for i := range f.buffers {
+		f.buffers[i] = make([]byte, f.size)
+	}
+	return nil
+}
+
+func (f *fakeCamera) GetBufferCount() uint32 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return uint32(len(f.buffers))
+}
+
+func (f *fakeCamera) GetPixelAspect() (uint32, uint32, error) { return 1, 1, nil }
+func (f *fakeCamera) SetIOMethod(uint32) error                { return nil }
+
+func (f *fakeCamera) GetFramerate() (float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.fps, nil
+}
+
+func (f *fakeCamera) SetFramerate(fps float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.maxRate > 0 && fps > f.maxRate {
+		fps = f.maxRate
+	}
+	f.fps = fps
+	return nil
+}
+
+func (f *fakeCamera) SetAutoWhiteBalance(auto bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sets = append(f.sets, fakeSet{awb, b2i(auto)})
+	return nil
+}
+
+// b2i returns 1 for true, and 0 for false.
+func b2i(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (f *fakeCamera) GetControls() map[webcam.ControlID]webcam.Control {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m := make(map[webcam.ControlID]webcam.Control)
+	for id := range f.controls {
+		m[id] = webcam.Control{Name: "control", Min: -1000, Max: 1000, Step: 1}
+	}
+	return m
+}
+
+func (f *fakeCamera) GetControl(id webcam.ControlID) (int32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.controls[id]
+	if !ok {
+		return 0, syscall.EINVAL
+	}
+	return v, nil
+}
+
+func (f *fakeCamera) SetControl(id webcam.ControlID, v int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.controls[id]; !ok {
+		return syscall.EINVAL
+	}
+	f.controls[id] = v
+	f.sets = append(f.sets, fakeSet{id, v})
+	return nil
+}
+
+// setLog returns the controls set so far.
+func (f *fakeCamera) setLog() []fakeSet {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeSet(nil), f.sets...)
+}
+
+func (f *fakeCamera) GetRectControl(webcam.ControlID) (image.Rectangle, error) {
+	return image.Rectangle{}, syscall.EINVAL
+}
+
+func (f *fakeCamera) SetRectControl(webcam.ControlID, image.Rectangle) (image.Rectangle, error) {
+	return image.Rectangle{}, syscall.EINVAL
+}
+
+func (f *fakeCamera) GetExtControls(ids []webcam.ControlID) (map[webcam.ControlID]int32, error) {
+	m := make(map[webcam.ControlID]int32)
+	for _, id := range ids {
+		v, err := f.GetControl(id)
+		if err != nil {
+			return nil, err
+		}
+		m[id] = v
+	}
+	return m, nil
+}
+
+func (f *fakeCamera) SetExtControls(m map[webcam.ControlID]int32) error {
+	for id, v := range m {
+		if err := f.SetControl(id, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeCamera) StartStreaming() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.buffers) == 0 {
+		return syscall.EINVAL
+	}
+	f.streaming = true
+	return nil
+}
+
+// StopStreaming returns all buffers to the camera, as the driver does.
+func (f *fakeCamera) StopStreaming() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.streaming = false
+	for i := range f.held {
+		f.held[i] = false
+	}
+	f.cond.Broadcast()
+	return nil
+}
+
+// WaitForFrame waits until a frame is ready, which is after interval
+// once a buffer is queued. If the script is done and the camera has
+// stalled, it waits for the timeout and times out. Scripted errors
+// are returned without waiting.
+func (f *fakeCamera) WaitForFrame(timeout uint32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.waits = append(f.waits, timeout)
+	if f.closed {
+		f.misuse("WaitForFrame on closed camera")
+		return syscall.EBADF
+	}
+	f.step = fakeStep{}
+	scripted := len(f.script) > 0
+	if scripted {
+		f.step, f.script = f.script[0], f.script[1:]
+		if f.step.wait != nil {
+			return f.step.wait
+		}
+	}
+	now := time.Now()
+	ready, deadline := now.Add(f.interval), now.Add(time.Duration(timeout)*time.Second)
+	// sync.Cond has no timed wait, so timers wake the waiter. They are
+	// only started if needed, so that benchmarks measure the Snapper.
+	var timers []*time.Timer
+	defer func() {
+		for _, t := range timers {
+			t.Stop()
+		}
+	}()
+	for {
+		switch {
+		case f.closed:
+			return syscall.EBADF
+		case !f.streaming:
+			// As with a driver, the wait ends once streaming stops,
+			// and reading the frame fails.
+			return nil
+		}
+		now := time.Now()
+		if (scripted || !(f.stall || f.hang)) && !now.Before(ready) && f.free() >= 0 {
+			return nil
+		}
+		if (scripted || !f.hang) && !now.Before(deadline) {
+			return &webcam.Timeout{}
+		}
+		if timers == nil {
+			timers = []*time.Timer{time.AfterFunc(ready.Sub(now), f.wake), time.AfterFunc(deadline.Sub(now), f.wake)}
+		}
+		f.cond.Wait()
+	}
+}
+
+// wake wakes WaitForFrame to check for a frame or timeout.
+func (f *fakeCamera) wake() {
+	f.mu.Lock()
+	f.cond.Broadcast()
+	f.mu.Unlock()
+}
+
+// free returns the index of a queued buffer, or -1 if all are dequeued.
+// It is called with mu held.
+func (f *fakeCamera) free() int {
+	for i, h := range f.held {
+		if !h {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetFrameInfo dequeues a buffer, filling it with the frame.
+// Each byte of a raw frame has the value of the sequence number.
+func (f *fakeCamera) GetFrameInfo() ([]byte, uint32, webcam.FrameInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.step.get != nil {
+		return nil, 0, webcam.FrameInfo{}, f.step.get
+	}
+	if !f.streaming {
+		return nil, 0, webcam.FrameInfo{}, syscall.EINVAL
+	}
+	i := f.free()
+	if i < 0 {
+		return nil, 0, webcam.FrameInfo{}, syscall.EAGAIN
+	}
+	f.seq++
+	f.held[i] = true
+	b := f.buffers[i]
+	if f.format == "MJPG" {
+		b = b[:copy(b, fakeJPEG(f.width, f.height))]
+	} else {
+		for j := range b {
+			b[j] = uint8(f.seq)
+		}
+	}
+	info := webcam.FrameInfo{Timestamp: time.Duration(time.Now().UnixNano()), Sequence: f.seq}
+	return b, uint32(i), info, nil
+}
+
+func (f *fakeCamera) ReleaseFrame(i uint32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case f.closed:
+		f.misuse("ReleaseFrame on closed camera")
+		return syscall.EBADF
+	case !f.streaming:
+		// The buffers have been returned by StopStreaming.
+		return syscall.EINVAL
+	case int(i) >= len(f.held) || !f.held[i]:
+		f.misuse("ReleaseFrame of buffer not dequeued")
+		return syscall.EINVAL
+	}
+	f.held[i] = false
+	f.cond.Broadcast()
+	return nil
+}
+
+// Close unmaps the buffers, overwriting them so that frames still
+// referring to them can be detected.
+func (f *fakeCamera) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		f.misuse("camera closed twice")
+	}
+	f.closed = true
+	f.streaming = false
+	for _, b := range f.buffers {
+		for j := range b {
+			b[j] = 0xEE
+		}
+	}
+	f.cond.Broadcast()
+	return nil
+}
+
+var (
+	fakeJPEGMu   sync.Mutex
+	fakeJPEGData = map[image.Point][]byte{}
+)
+
+// fakeJPEG returns a JPEG image of the size used as an MJPG frame.
+// The image is encoded once for each size.
+func fakeJPEG(w, h int) []byte {
+	fakeJPEGMu.Lock()
+	defer fakeJPEGMu.Unlock()
+	size := image.Pt(w, h)
+	if b, ok := fakeJPEGData[size]; ok {
+		return b
+	}
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	var b bytes.Buffer
+	jpeg.Encode(&b, img, nil)
+	fakeJPEGData[size] = b.Bytes()
+	return b.Bytes()
+}
+
+// fakeDevice replaces openCamera for a test, opening a new fakeCamera
+// each time the device is opened.
+type fakeDevice struct {
+	mu      sync.Mutex
+	newCam  func() *fakeCamera
+	cams    []*fakeCamera
+	openErr error // If set, returned by openCamera.
+}
+
+// useFake replaces openCamera for the duration of the test.
+func useFake(t testing.TB, newCam func() *fakeCamera) *fakeDevice {
+	d := &fakeDevice{newCam: newCam}
+	orig := openCamera
+	openCamera = func(string) (camera, error) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.openErr != nil {
+			return nil, d.openErr
+		}
+		cam := d.newCam()
+		d.cams = append(d.cams, cam)
+		return cam, nil
+	}
+	t.Cleanup(func() {
+		openCamera = orig
+		for _, cam := range d.opened() {
+			cam.check(t)
+		}
+	})
+	return d
+}
+
+// opened returns the cameras opened so far.
+func (d *fakeDevice) opened() []*fakeCamera {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*fakeCamera(nil), d.cams...)
+}
+
+// last returns the camera most recently opened.
+func (d *fakeDevice) last() *fakeCamera {
+	cams := d.opened()
+	if len(cams) == 0 {
+		return nil
+	}
+	return cams[len(cams)-1]
+}
+
+// openFake opens a Snapper on a fake YUYV camera, calling setup (if set)
+// on each camera as it is opened. The Snapper is closed when the test ends.
+func openFake(t testing.TB, c *Snapper, setup func(*fakeCamera)) *fakeDevice {
+	t.Helper()
+	d := useFake(t, func() *fakeCamera {
+		cam := newFakeCamera("YUYV", 4, 2)
+		if setup != nil {
+			setup(cam)
+		}
+		return cam
+	})
+	c.Buffers = 4
+	if err := c.Open("/dev/fake", "YUYV", 4, 2); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return d
+}
+
+func TestSnap(t *testing.T) {
+	c := NewSnapper()
+	d := openFake(t, c, nil)
+	var last uint32
+	for i := 0; i < 5; i++ {
+		f, err := c.Snap()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Bounds() != image.Rect(0, 0, 4, 2) {
+			t.Fatalf("bounds %v", f.Bounds())
+		}
+		seq := frame.SequenceOf(f)
+		if seq <= last {
+			t.Fatalf("sequence %d after %d", seq, last)
+		}
+		if f.At(0, 0).(color.YCbCr).Y != uint8(seq) {
+			t.Fatalf("frame %d has the data of another frame", seq)
+		}
+		last = seq
+		f.Release()
+	}
+	cam := d.last()
+	c.Close()
+	if !cam.isClosed() {
+		t.Fatal("camera not closed")
+	}
+	if _, err := c.Snap(); !errors.Is(err, ErrNotOpen) {
+		t.Fatalf("Snap after Close: got %v, want ErrNotOpen", err)
+	}
+}
+
+func TestSnapHeldFrames(t *testing.T) {
+	c := NewSnapper()
+	d := openFake(t, c, nil)
+	var held []frame.Frame
+	for i := 0; i < 3; i++ {
+		f, err := c.Snap()
+		if err != nil {
+			t.Fatal(err)
+		}
+		held = append(held, f)
+	}
+	if n := c.Outstanding(); n != 3 {
+		t.Fatalf("Outstanding: got %d, want 3", n)
+	}
+	for _, f := range held {
+		f.Release()
+	}
+	if n := c.Outstanding(); n != 0 {
+		t.Fatalf("Outstanding after release: got %d, want 0", n)
+	}
+	// Only the frame waiting in the stream is still dequeued.
+	if n := d.last().dequeued(); n > 1 {
+		t.Fatalf("%d buffers dequeued after release", n)
+	}
+}
+
+func TestCaptureError(t *testing.T) {
+	c := NewSnapper()
+	errFake := errors.New("fake failure")
+	openFake(t, c, func(cam *fakeCamera) {
+		cam.run(fakeStep{}, fakeStep{get: errFake})
+	})
+	var err error
+	for err == nil {
+		var f frame.Frame
+		if f, err = c.Snap(); err == nil {
+			f.Release()
+		}
+	}
+	if !errors.Is(err, errFake) {
+		t.Fatalf("Snap: got %v, want %v", err, errFake)
+	}
+	select {
+	case err := <-c.Errors():
+		if !errors.Is(err, errFake) {
+			t.Fatalf("Errors: got %v, want %v", err, errFake)
+		}
+	default:
+		t.Fatal("error not reported")
+	}
+}
+
+func TestDeviceGone(t *testing.T) {
+	c := NewSnapper()
+	openFake(t, c, func(cam *fakeCamera) {
+		cam.run(fakeStep{wait: syscall.ENODEV})
+	})
+	if _, err := c.Snap(); !errors.Is(err, ErrDeviceGone) {
+		t.Fatalf("Snap: got %v, want ErrDeviceGone", err)
+	}
+}
+
+func TestTimeoutsIgnored(t *testing.T) {
+	c := NewSnapper()
+	openFake(t, c, func(cam *fakeCamera) {
+		cam.run(timeoutStep, timeoutStep, timeoutStep)
+	})
+	f, err := c.Snap()
+	if err != nil {
+		t.Fatalf("Snap after timeouts: %v", err)
+	}
+	f.Release()
+}
+
+func TestOpenFailure(t *testing.T) {
+	c := NewSnapper()
+	d := useFake(t, func() *fakeCamera { return newFakeCamera("YUYV", 4, 2) })
+	if err := c.Open("/dev/fake", "RGB3", 4, 2); err == nil {
+		t.Fatal("Open succeeded with unsupported format")
+	}
+	if !d.last().isClosed() {
+		t.Fatal("camera not closed after failed Open")
+	}
+	if err := c.Open("/dev/fake", "YUYV", 4, 2); err != nil {
+		t.Fatalf("Open after failure: %v", err)
+	}
+	c.Close()
+	d.openErr = syscall.ENOENT
+	if err := c.Open("/dev/fake", "YUYV", 4, 2); !errors.Is(err, syscall.ENOENT) {
+		t.Fatalf("Open: got %v, want ENOENT", err)
+	}
+}
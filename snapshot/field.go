@@ -0,0 +1,32 @@
+package snapshot
+
+import (
+	"fmt"
+
+	"github.com/aamcrae/webcam"
+)
+
+// FieldMode selects how interlaced fields are delivered by the camera.
+type FieldMode uint32
+
+const (
+	FieldAny        = FieldMode(webcam.V4L2_FIELD_ANY)  // Driver chooses (default)
+	FieldNone       = FieldMode(webcam.V4L2_FIELD_NONE) // Progressive
+	FieldInterlaced = FieldMode(webcam.V4L2_FIELD_INTERLACED)
+	FieldTop        = FieldMode(webcam.V4L2_FIELD_TOP)
+	FieldBottom     = FieldMode(webcam.V4L2_FIELD_BOTTOM)
+	FieldAlternate  = FieldMode(webcam.V4L2_FIELD_ALTERNATE)
+)
+
+// SetField selects the field mode requested when the camera is opened.
+// It must be called before Open. Interlaced frames can be converted
+// with frame.Deinterlace.
+func (c *Snapper) SetField(f FieldMode) error {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if c.state != stateClosed {
+		return fmt.Errorf("SetField: %w", ErrAlreadyOpen)
+	}
+	c.field = f
+	return nil
+}
@@ -0,0 +1,28 @@
+package snapshot
+
+import (
+	"time"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// FlushAndSnap discards any frames captured before the call, and returns
+// the next frame. This ensures that the frame shows the scene now, rather
+// than a stale frame waiting in the queue after the camera has been idle.
+// Frames are discarded until one is timestamped after the call, or the
+// number of frames that can be queued (the buffer count) has been
+// discarded, in case the driver timestamps are not reliable.
+func (c *Snapper) FlushAndSnap() (frame.Frame, error) {
+	start := time.Now()
+	c.discardStale()
+	for i := 0; ; i++ {
+		f, err := c.snap()
+		if err != nil {
+			return nil, err
+		}
+		if !frame.TimestampOf(f).Before(start) || i >= int(c.Buffers) {
+			return f, nil
+		}
+		f.Release()
+	}
+}
@@ -0,0 +1,67 @@
+package snapshot
+
+import (
+	"errors"
+	"image"
+	"syscall"
+
+	"github.com/aamcrae/webcam"
+)
+
+// SetFocusWindow sets the region of the frame used by the camera's
+// autofocus, for cameras that support the UVC region of interest controls.
+// The rectangle is in pixels of the current frame size, as the driver
+// expects, and is limited to the frame. ErrControlUnsupported is returned
+// if the camera does not have the controls.
+func (c *Snapper) SetFocusWindow(r image.Rectangle) error {
+	cam, err := c.openCam()
+	if err != nil {
+		return err
+	}
+	auto := webcam.ControlID(webcam.V4L2_CID_UVC_REGION_OF_INTEREST_AUTO)
+	if _, ok := cam.GetControls()[auto]; !ok {
+		return ErrControlUnsupported
+	}
+	c.stateMu.Lock()
+	r = r.Intersect(image.Rect(0, 0, c.width, c.height))
+	c.stateMu.Unlock()
+	if r.Empty() {
+		return errors.New("focus window is outside the frame")
+	}
+	rect := webcam.ControlID(webcam.V4L2_CID_UVC_REGION_OF_INTEREST_RECT)
+	if _, err := cam.SetRectControl(rect, r); err != nil {
+		return rectError(err)
+	}
+	// Use the region for autofocus, keeping any other uses of it.
+	flags, err := cam.GetControl(auto)
+	if err != nil {
+		return err
+	}
+	if flags&webcam.V4L2_UVC_REGION_OF_INTEREST_AUTO_FOCUS != 0 {
+		return nil
+	}
+	return cam.SetControl(auto, flags|webcam.V4L2_UVC_REGION_OF_INTEREST_AUTO_FOCUS)
+}
+
+// FocusWindow returns the region of the frame used by the camera's autofocus.
+func (c *Snapper) FocusWindow() (image.Rectangle, error) {
+	cam, err := c.openCam()
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	r, err := cam.GetRectControl(webcam.ControlID(webcam.V4L2_CID_UVC_REGION_OF_INTEREST_RECT))
+	if err != nil {
+		return image.Rectangle{}, rectError(err)
+	}
+	return r, nil
+}
+
+// rectError reports a rectangle control that the driver does not
+// recognise as unsupported. Compound controls are not listed
+// by GetControls, so are only found to be missing when used.
+func rectError(err error) error {
+	if errors.Is(err, syscall.EINVAL) {
+		return ErrControlUnsupported
+	}
+	return err
+}
@@ -0,0 +1,170 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// Resolutions with more pixels than this prefer MJPEG over YUYV,
+// since uncompressed frames at high resolutions limit the frame rate.
+const mjpegThreshold = 640 * 480
+
+// Format returns the format selected when the camera was opened,
+// which is that of the driver if it differs from the one requested.
+func (c *Snapper) Format() frame.FourCC {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.format
+}
+
+// jpegFormats lists formats in order of the cost of producing a JPEG:
+// compressed formats can be passed through, and YCbCr formats avoid
+// conversion through RGB.
+var jpegFormats = []frame.FourCC{"MJPG", "JPEG", "YU12", "YV12", "YUYV", "RGB3", "BGR3", "XR24", "BX24"}
+
+// OpenForJPEG opens the camera using the format that is cheapest to
+// serve as JPEG, preferring MJPEG (which can be passed through without
+// encoding), then planar YUV 4:2:0, then other YCbCr formats and
+// finally RGB. The chosen format is returned so that the caller
+// can tell whether passthrough applies.
+func (c *Snapper) OpenForJPEG(device string, w, h int) (frame.FourCC, error) {
+	if err := c.openPrefs(device, "", w, h, jpegFormats); err != nil {
+		return "", err
+	}
+	return c.Format(), nil
+}
+
+// chooseFormat selects a format supported by both the camera and a framer
+// at the requested resolution, preferring MJPEG at high resolutions and
+// YUYV at low resolutions, unless other preferences have been set.
+func (c *Snapper) chooseFormat(w, h int) (frame.FourCC, error) {
+	prefs := c.prefs
+	if prefs == nil {
+		prefs = []frame.FourCC{"YUYV", "MJPG"}
+		if w*h > mjpegThreshold {
+			prefs = []frame.FourCC{"MJPG", "YUYV"}
+		}
+	}
+	formats := c.cam.GetSupportedFormats()
+	// Add the remaining formats in a stable order.
+	var others []frame.FourCC
+	for pf := range formats {
+		f := frame.PixelFormatToFourCC(pf)
+		if !hasFormat(prefs, f) {
+			others = append(others, f)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool { return others[i] < others[j] })
+	for _, f := range append(prefs, others...) {
+		// Compressed video cannot be decoded, so must be asked for.
+		if !frame.HasFramer(f) || frame.IsEncoded(f) {
+			continue
+		}
+		pf, err := frame.FourCCToPixelFormat(f)
+		if err != nil {
+			continue
+		}
+		if _, ok := formats[pf]; !ok {
+			continue
+		}
+		for _, fs := range c.cam.GetSupportedFrameSizes(pf) {
+			if Match(fs, w, h) {
+				return f, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no supported format for resolution %dx%d", w, h)
+}
+
+// defaultFormat returns the format and its default resolution.
+// If format is empty, the camera's current format is used.
+func (c *Snapper) defaultFormat(format frame.FourCC) (frame.FourCC, int, int, error) {
+	if format == "" {
+		pf, _, _, err := c.cam.GetImageFormat()
+		if err != nil {
+			return "", 0, 0, err
+		}
+		format = frame.PixelFormatToFourCC(pf)
+		if !frame.HasFramer(format) || frame.IsEncoded(format) {
+			return "", 0, 0, fmt.Errorf("no framer for default format %s", format)
+		}
+	}
+	pf, err := frame.FourCCToPixelFormat(format)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	w, h := defaultFrameSize(c.cam, pf)
+	if w == 0 {
+		return "", 0, 0, fmt.Errorf("no frame sizes for format %s", format)
+	}
+	return format, w, h, nil
+}
+
+func hasFormat(formats []frame.FourCC, f frame.FourCC) bool {
+	for _, v := range formats {
+		if v == f {
+			return true
+		}
+	}
+	return false
+}
+
+// SetStride forces the framers to use the given number of bytes per line,
+// instead of the value reported by the driver. This is a workaround of
+// last resort for drivers that report an incorrect stride, which causes
+// frames to be decoded as skewed or garbled images.
+// A value of 0 restores the driver's stride.
+// It should be called before Open, or while no frames are being read.
+// While the camera is open, an error is returned if frames are too short
+// for the stride.
+func (c *Snapper) SetStride(bytes int) error {
+	if bytes < 0 {
+		return fmt.Errorf("SetStride: illegal stride %d", bytes)
+	}
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if c.state != stateClosed && bytes != 0 {
+		if need, err := frame.FrameSizeBytes(c.framerFormat, c.width, c.height, bytes); err == nil && need > c.size {
+			return fmt.Errorf("SetStride: stride %d needs frames of %d bytes, have %d", bytes, need, c.size)
+		}
+	}
+	c.strideOverride = bytes
+	if c.state != stateClosed {
+		return c.makeFramer(c.framerFormat)
+	}
+	return nil
+}
+
+// SetFramer changes the framer used to decode frames to that of another
+// format, while the camera continues streaming. The raw frames are not
+// changed, so this is used to diagnose drivers that mislabel the format
+// (e.g to view a BGR3 stream that is reported as RGB3).
+// An error is returned unless the format is that of the device, or both
+// formats have a fixed frame size and the sizes are the same.
+// The framer is kept if the camera is reopened after being paused
+// or reconnected, and reverts to the device format on Open.
+func (c *Snapper) SetFramer(format frame.FourCC) error {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if err := c.ready(); err != nil {
+		return fmt.Errorf("SetFramer: %w", err)
+	}
+	if format != c.format {
+		curSize, curErr := frame.FrameSizeBytes(c.format, c.width, c.height, 0)
+		newSize, newErr := frame.FrameSizeBytes(format, c.width, c.height, 0)
+		if curErr != nil || newErr != nil || curSize != newSize {
+			return fmt.Errorf("SetFramer: format %s is not compatible with %s", format, c.format)
+		}
+	}
+	return c.makeFramer(format)
+}
+
+// FramerFormat returns the format used to decode frames, which is that
+// of the device unless changed by SetFramer.
+func (c *Snapper) FramerFormat() frame.FourCC {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.framerFormat
+}
@@ -0,0 +1,252 @@
+package snapshot
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+func TestSetStrideWhileStreaming(t *testing.T) {
+	c := NewSnapper()
+	openFake(t, c, nil)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			f, err := c.Snap()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			f.Release()
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if err := c.SetStride((i % 2) * 8); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.SetFramer("YUYV"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	if err := c.SetStride(-1); err == nil {
+		t.Fatal("no error for negative stride")
+	}
+	if err := c.SetStride(16); err == nil {
+		t.Fatal("no error for stride too large for the frame size")
+	}
+}
+
+func TestSetFramer(t *testing.T) {
+	c := NewSnapper()
+	if err := c.SetFramer("YUYV"); err == nil {
+		t.Fatal("no error for SetFramer before Open")
+	}
+	openFake(t, c, nil)
+	if err := c.SetFramer("RGB3"); err == nil {
+		t.Fatal("no error for format with a different frame size")
+	}
+	if err := c.SetFramer("MJPG"); err == nil {
+		t.Fatal("no error for compressed format")
+	}
+	if f := c.Format(); f != "YUYV" {
+		t.Fatalf("Format: got %s after failed SetFramer", f)
+	}
+	if f := c.FramerFormat(); f != "YUYV" {
+		t.Fatalf("FramerFormat: got %s after failed SetFramer", f)
+	}
+}
+
+func TestSetFramerCompressed(t *testing.T) {
+	c := NewSnapper()
+	useFake(t, func() *fakeCamera { return newFakeCamera("MJPG", 16, 8) })
+	if err := c.Open("/dev/fake", "MJPG", 16, 8); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	// Compressed formats have no known frame size, so only the
+	// format of the device is accepted.
+	if err := c.SetFramer("H264"); err == nil {
+		t.Fatal("no error for a different compressed format")
+	}
+	if err := c.SetFramer("MJPG"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetFramerResume(t *testing.T) {
+	c := NewSnapper()
+	c.IdleTimeout = 20 * time.Millisecond
+	d := useFake(t, func() *fakeCamera { return newFakeCamera("RGB3", 4, 2) })
+	if err := c.Open("/dev/fake", "RGB3", 4, 2); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.SetFramer("BGR3"); err != nil {
+		t.Fatal(err)
+	}
+	check := func(when string) {
+		t.Helper()
+		if f := c.Format(); f != "RGB3" {
+			t.Fatalf("Format %s: got %s, want the device format", when, f)
+		}
+		if f := c.FramerFormat(); f != "BGR3" {
+			t.Fatalf("FramerFormat %s: got %s", when, f)
+		}
+	}
+	check("after SetFramer")
+	if !waitState(t, c, statePaused) {
+		t.Fatal("streaming not paused when idle")
+	}
+	// The device is reopened with its own format, and the framer is kept.
+	f, err := c.Snap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Release()
+	if len(d.opened()) != 2 {
+		t.Fatal("camera not reopened by Snap")
+	}
+	check("after resume")
+	c.Close()
+	if err := c.Open("/dev/fake", "RGB3", 4, 2); err != nil {
+		t.Fatal(err)
+	}
+	if f := c.FramerFormat(); f != "RGB3" {
+		t.Fatalf("FramerFormat after Open: got %s", f)
+	}
+}
+
+func TestOpenForJPEG(t *testing.T) {
+	c := NewSnapper()
+	useFake(t, func() *fakeCamera {
+		cam := newFakeCamera("YUYV", 4, 2)
+		cam.formats = []frame.FourCC{"RGB3", "YU12"}
+		return cam
+	})
+	format, err := c.OpenForJPEG("/dev/fake", 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "YU12" || c.Format() != "YU12" {
+		t.Fatalf("OpenForJPEG: got %s (Format %s), want YU12", format, c.Format())
+	}
+	c.Close()
+	// The JPEG preferences only apply to OpenForJPEG.
+	if err := c.Open("/dev/fake", "", 4, 2); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if f := c.Format(); f != "YUYV" {
+		t.Fatalf("Open: got format %s, want YUYV", f)
+	}
+}
+
+func TestOpenForJPEGConcurrent(t *testing.T) {
+	c := NewSnapper()
+	useFake(t, func() *fakeCamera {
+		cam := newFakeCamera("YUYV", 4, 2)
+		cam.formats = []frame.FourCC{"YU12"}
+		return cam
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := c.OpenForJPEG("/dev/fake", 4, 2); err != nil && !errors.Is(err, ErrAlreadyOpen) {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			c.Close()
+		}()
+	}
+	wg.Wait()
+	c.Close()
+}
+
+func TestChooseFormat(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		formats []frame.FourCC // Formats offered, the first being current.
+		w, h    int            // Resolution offered by the camera.
+		prefs   []frame.FourCC
+		want    frame.FourCC // Empty if no format is suitable.
+	}{
+		{"low res", []frame.FourCC{"MJPG", "YUYV"}, 320, 240, nil, "YUYV"},
+		{"high res", []frame.FourCC{"YUYV", "MJPG"}, 1280, 720, nil, "MJPG"},
+		{"preferred missing", []frame.FourCC{"YUYV", "H264"}, 1280, 720, nil, "YUYV"},
+		{"others sorted", []frame.FourCC{"RGB3", "GREY"}, 320, 240, nil, "GREY"},
+		{"encoded only", []frame.FourCC{"H264"}, 320, 240, nil, ""},
+		{"preferences", []frame.FourCC{"YUYV", "RGB3", "YU12"}, 320, 240, jpegFormats, "YU12"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cam := newFakeCamera(tc.formats[0], tc.w, tc.h)
+			cam.formats = tc.formats[1:]
+			c := NewSnapper()
+			c.cam = &streamCam{camera: cam}
+			c.prefs = tc.prefs
+			got, err := c.chooseFormat(tc.w, tc.h)
+			if tc.want == "" {
+				if err == nil {
+					t.Fatalf("got %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Fatalf("got %s, want %s", got, tc.want)
+			}
+			// No format is offered at other resolutions.
+			if got, err := c.chooseFormat(tc.w/2, tc.h/2); err == nil {
+				t.Fatalf("got %s for an unsupported resolution", got)
+			}
+		})
+	}
+}
+
+func TestRequireExactFormat(t *testing.T) {
+	for _, exact := range []bool{true, false} {
+		c := NewSnapper()
+		c.RequireExactFormat = exact
+		d := useFake(t, func() *fakeCamera {
+			cam := newFakeCamera("YUYV", 4, 2)
+			cam.formats = []frame.FourCC{"RGB3"}
+			cam.substitute = "YUYV"
+			return cam
+		})
+		err := c.Open("/dev/fake", "RGB3", 4, 2)
+		if exact {
+			if err == nil {
+				c.Close()
+				t.Fatal("no error when the driver selects a different format")
+			}
+			if !d.last().isClosed() {
+				t.Fatal("camera not closed after a format mismatch")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The frames are decoded in the format selected by the driver.
+		if f := c.Format(); f != "YUYV" {
+			t.Fatalf("Format: got %s, want the driver's format", f)
+		}
+		f, err := c.Snap()
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Release()
+		c.Close()
+	}
+}
@@ -0,0 +1,34 @@
+package snapshot
+
+import (
+	"fmt"
+)
+
+// FrameRate returns the frame rate negotiated with the driver, which
+// may differ from the rate requested with SetFrameRate.
+func (c *Snapper) FrameRate() (float64, error) {
+	cam, err := c.openCam()
+	if err != nil {
+		return 0, err
+	}
+	return cam.GetFramerate()
+}
+
+// SetFrameRate requests a frame rate from the camera. If the camera is
+// not streaming, the rate is applied when it is opened or resumed.
+// Drivers may clamp the rate to one that is supported, so use FrameRate
+// to read the actual value.
+// Some drivers do not allow the rate to be changed while streaming,
+// in which case the camera should be reopened.
+func (c *Snapper) SetFrameRate(fps float64) error {
+	if fps <= 0 {
+		return fmt.Errorf("illegal frame rate %g", fps)
+	}
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	c.fps = fps
+	if c.state != stateStreaming {
+		return nil
+	}
+	return c.getCam().SetFramerate(fps)
+}
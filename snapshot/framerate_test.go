@@ -0,0 +1,33 @@
+package snapshot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFrameRateClamped(t *testing.T) {
+	c := NewSnapper()
+	if _, err := c.FrameRate(); !errors.Is(err, ErrNotOpen) {
+		t.Fatalf("FrameRate before Open: got %v, want ErrNotOpen", err)
+	}
+	// A rate set before Open is applied when the camera is opened.
+	if err := c.SetFrameRate(10); err != nil {
+		t.Fatal(err)
+	}
+	openFake(t, c, func(cam *fakeCamera) {
+		cam.maxRate = 15
+	})
+	if fps, err := c.FrameRate(); err != nil || fps != 10 {
+		t.Fatalf("FrameRate after Open: got %g, %v, want 10", fps, err)
+	}
+	// The driver clamps the rate, and FrameRate reports the clamped rate.
+	if err := c.SetFrameRate(30); err != nil {
+		t.Fatal(err)
+	}
+	if fps, err := c.FrameRate(); err != nil || fps != 15 {
+		t.Fatalf("FrameRate: got %g, %v, want 15", fps, err)
+	}
+	if err := c.SetFrameRate(0); err == nil {
+		t.Fatal("no error for a zero frame rate")
+	}
+}
@@ -0,0 +1,70 @@
+package snapshot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// Group captures frames from several cameras at once,
+// e.g for stereo or multi-view rigs.
+type Group struct {
+	Snappers []*Snapper
+}
+
+// NewGroup creates a Group of open Snappers.
+func NewGroup(s ...*Snapper) *Group {
+	return &Group{Snappers: s}
+}
+
+// SnapAll returns a frame from each camera, in the order of Snappers.
+// Stale frames are discarded and the cameras are read concurrently,
+// so that the frames are captured as close together as possible.
+// The frame timestamps can be used to check how closely the frames
+// are synchronised (see Skew). If any camera fails, the frames from
+// the other cameras are released and the error is returned.
+func (g *Group) SnapAll() ([]frame.Frame, error) {
+	frames := make([]frame.Frame, len(g.Snappers))
+	errs := make([]error, len(g.Snappers))
+	for _, s := range g.Snappers {
+		s.discardStale()
+	}
+	var wg sync.WaitGroup
+	for i, s := range g.Snappers {
+		wg.Add(1)
+		go func(i int, s *Snapper) {
+			defer wg.Done()
+			frames[i], errs[i] = s.snap()
+		}(i, s)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			for _, f := range frames {
+				if f != nil {
+					f.Release()
+				}
+			}
+			return nil, fmt.Errorf("camera %d: %w", i, err)
+		}
+	}
+	return frames, nil
+}
+
+// Skew returns the difference between the earliest and latest
+// timestamps of the frames.
+func Skew(frames []frame.Frame) time.Duration {
+	var min, max time.Time
+	for i, f := range frames {
+		t := frame.TimestampOf(f)
+		if i == 0 || t.Before(min) {
+			min = t
+		}
+		if i == 0 || t.After(max) {
+			max = t
+		}
+	}
+	return max.Sub(min)
+}
@@ -0,0 +1,59 @@
+package snapshot
+
+import (
+	"errors"
+	"image"
+
+	"github.com/aamcrae/webcam"
+	"github.com/aamcrae/webcam/frame"
+)
+
+// CaptureHDR snaps one frame at each of the absolute exposure values,
+// and merges them into a single tone-mapped image using exposure fusion
+// (see frame.FuseExposures). The scene and camera are assumed to be static,
+// so the frames are not aligned. Auto exposure is disabled while the
+// frames are taken, and the previous exposure settings are then restored.
+func (c *Snapper) CaptureHDR(exposures []int32) (*image.RGBA, error) {
+	if len(exposures) == 0 {
+		return nil, errors.New("no exposures given")
+	}
+	cam, err := c.openCam()
+	if err != nil {
+		return nil, err
+	}
+	expAuto := webcam.ControlID(webcam.V4L2_CID_EXPOSURE_AUTO)
+	expAbs := webcam.ControlID(webcam.V4L2_CID_EXPOSURE_ABSOLUTE)
+	if _, ok := cam.GetControls()[expAbs]; !ok {
+		return nil, ErrControlUnsupported
+	}
+	prev, err := cam.GetExtControls([]webcam.ControlID{expAuto, expAbs})
+	if err != nil {
+		return nil, err
+	}
+	imgs := make([]image.Image, 0, len(exposures))
+	for _, e := range exposures {
+		err = cam.SetExtControls(map[webcam.ControlID]int32{expAuto: webcam.V4L2_EXPOSURE_MANUAL, expAbs: e})
+		if err != nil {
+			break
+		}
+		var f, cp frame.Frame
+		if f, err = c.settleAndSnap(); err != nil {
+			break
+		}
+		// Copy the frame so that its buffer can be released.
+		cp, err = c.Copy(f)
+		f.Release()
+		if err != nil {
+			break
+		}
+		imgs = append(imgs, cp)
+	}
+	// Restore the previous settings even if a snap failed.
+	if rerr := cam.SetExtControls(prev); err == nil {
+		err = rerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return frame.FuseExposures(imgs)
+}
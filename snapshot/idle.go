@@ -0,0 +1,48 @@
+package snapshot
+
+import (
+	"time"
+)
+
+// Shortest interval between checks for an idle Snapper.
+const minIdleCheck = 10 * time.Millisecond
+
+// watchIdle pauses streaming when the Snapper has been idle for
+// IdleTimeout, until stop is closed.
+func (c *Snapper) watchIdle(stop chan struct{}) {
+	interval := c.IdleTimeout / 4
+	if interval < minIdleCheck {
+		interval = minIdleCheck
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			c.pauseIfIdle()
+		}
+	}
+}
+
+// pauseIfIdle stops streaming and closes the device if no frames
+// have been read for IdleTimeout, no Snap is waiting for a frame,
+// and no frames are held, since closing the device unmaps their buffers.
+func (c *Snapper) pauseIfIdle() {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if c.state == stateStreaming && c.readers == 0 && c.Outstanding() == 0 && time.Since(c.lastUse) >= c.IdleTimeout {
+		c.stopCapture(statePaused)
+	}
+}
+
+// resume reopens the camera with the settings used by Open.
+// It is called with stateMu held.
+func (c *Snapper) resume() error {
+	if err := c.open(c.device, c.format, c.openW, c.openH); err != nil {
+		return err
+	}
+	c.state = stateStreaming
+	return nil
+}
@@ -0,0 +1,62 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+)
+
+// waitState waits up to a second for the Snapper to reach the state.
+func waitState(t *testing.T, c *Snapper, want state) bool {
+	t.Helper()
+	for end := time.Now().Add(time.Second); time.Now().Before(end); time.Sleep(time.Millisecond) {
+		if c.getState() == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestIdlePauseResume(t *testing.T) {
+	c := NewSnapper()
+	c.IdleTimeout = 20 * time.Millisecond
+	d := openFake(t, c, nil)
+	if !waitState(t, c, statePaused) {
+		t.Fatal("streaming not paused when idle")
+	}
+	if !d.last().isClosed() {
+		t.Fatal("camera not closed when paused")
+	}
+	f, err := c.Snap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Release()
+	if cams := d.opened(); len(cams) != 2 || cams[1].isClosed() {
+		t.Fatal("camera not reopened by Snap")
+	}
+	if c.getState() != stateStreaming && c.getState() != statePaused {
+		t.Fatalf("state %d after resume", c.getState())
+	}
+}
+
+func TestIdleHeldFrame(t *testing.T) {
+	c := NewSnapper()
+	c.IdleTimeout = 10 * time.Millisecond
+	d := openFake(t, c, nil)
+	f, err := c.Snap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := f.At(0, 0)
+	time.Sleep(10 * c.IdleTimeout)
+	if c.getState() != stateStreaming || d.last().isClosed() {
+		t.Fatal("streaming paused while a frame is held")
+	}
+	if got := f.At(0, 0); got != want {
+		t.Fatalf("held frame changed from %v to %v", want, got)
+	}
+	f.Release()
+	if !waitState(t, c, statePaused) {
+		t.Fatal("streaming not paused once the frame was released")
+	}
+}
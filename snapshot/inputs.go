@@ -0,0 +1,61 @@
+package snapshot
+
+import (
+	"fmt"
+
+	"github.com/aamcrae/webcam"
+)
+
+// InputInfo describes a video input, such as a composite,
+// S-Video or HDMI connector of a capture card.
+type InputInfo struct {
+	Index   int
+	Name    string
+	Camera  bool // The input is a camera rather than a tuner.
+	Current bool // The input is currently selected.
+	// Signal status, which drivers only report for the current input.
+	NoPower  bool
+	NoSignal bool
+}
+
+// Inputs returns the video inputs of the open device.
+func (c *Snapper) Inputs() ([]InputInfo, error) {
+	cam, err := c.openCam()
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := cam.GetInputs()
+	if err != nil {
+		return nil, err
+	}
+	cur, err := cam.GetInput()
+	if err != nil {
+		return nil, err
+	}
+	var info []InputInfo
+	for _, in := range inputs {
+		info = append(info, InputInfo{
+			Index:    int(in.Index),
+			Name:     in.Name,
+			Camera:   in.Type == webcam.V4L2_INPUT_TYPE_CAMERA,
+			Current:  in.Index == cur,
+			NoPower:  in.Status&webcam.V4L2_IN_ST_NO_POWER != 0,
+			NoSignal: in.Status&webcam.V4L2_IN_ST_NO_SIGNAL != 0,
+		})
+	}
+	return info, nil
+}
+
+// SetInput selects the video input to capture from. Drivers may
+// refuse to change the input while streaming, in which case the
+// camera must be closed and reopened after selecting a new input.
+func (c *Snapper) SetInput(index int) error {
+	cam, err := c.openCam()
+	if err != nil {
+		return err
+	}
+	if index < 0 {
+		return fmt.Errorf("illegal input %d", index)
+	}
+	return cam.SetInput(uint32(index))
+}
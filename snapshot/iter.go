@@ -0,0 +1,49 @@
+//go:build go1.23
+// +build go1.23
+
+package snapshot
+
+import (
+	"context"
+	"iter"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// Frames returns an iterator over the frames read from the camera, e.g:
+//
+//	for f, err := range c.Frames(ctx) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// Each frame is released before the next frame is read, or when the loop
+// ends, so the loop body must copy any data it wishes to keep.
+// If reading a frame fails, or the context is cancelled, the error
+// is yielded and the iteration stops.
+func (c *Snapper) Frames(ctx context.Context) iter.Seq2[frame.Frame, error] {
+	return func(yield func(frame.Frame, error) bool) {
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			f, err := c.Snap()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yieldAndRelease(f, yield) {
+				return
+			}
+		}
+	}
+}
+
+// yieldAndRelease yields the frame, and releases it even if the loop body panics.
+func yieldAndRelease(f frame.Frame, yield func(frame.Frame, error) bool) bool {
+	defer f.Release()
+	return yield(f, nil)
+}
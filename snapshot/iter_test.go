@@ -0,0 +1,76 @@
+//go:build go1.23
+// +build go1.23
+
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+func TestFrames(t *testing.T) {
+	c := NewSnapper()
+	openFake(t, c, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := 0
+	var last uint32
+	for f, err := range c.Frames(ctx) {
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("Frames: got %v, want context.Canceled", err)
+			}
+			if f != nil {
+				t.Fatal("frame yielded with an error")
+			}
+			break
+		}
+		if seq := frame.SequenceOf(f); seq <= last {
+			t.Fatalf("frame %d yielded after frame %d", seq, last)
+		} else {
+			last = seq
+		}
+		if n++; n == 3 {
+			cancel()
+		}
+	}
+	if n != 3 {
+		t.Fatalf("%d frames yielded before cancel, want 3", n)
+	}
+	// Each frame was released once the next was read.
+	if c.Outstanding() != 0 {
+		t.Fatalf("Outstanding: got %d, want 0", c.Outstanding())
+	}
+}
+
+func TestFramesBreak(t *testing.T) {
+	c := NewSnapper()
+	openFake(t, c, nil)
+	for _, err := range c.Frames(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		break
+	}
+	if c.Outstanding() != 0 {
+		t.Fatalf("Outstanding after break: got %d, want 0", c.Outstanding())
+	}
+}
+
+func TestFramesError(t *testing.T) {
+	c := NewSnapper()
+	openFake(t, c, nil)
+	c.Close()
+	n := 0
+	for _, err := range c.Frames(context.Background()) {
+		if n++; !errors.Is(err, ErrNotOpen) {
+			t.Fatalf("Frames: got %v, want ErrNotOpen", err)
+		}
+	}
+	if n != 1 {
+		t.Fatalf("%d values yielded, want 1", n)
+	}
+}
@@ -0,0 +1,97 @@
+package snapshot
+
+import (
+	"errors"
+	"log"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// ErrTooManyOutstanding is returned by Snap and TrySnap when MaxOutstanding
+// frames have not been released.
+var ErrTooManyOutstanding = errors.New("too many frames not released")
+
+// leakFrame wraps a frame to detect when it is garbage collected
+// without Release being called.
+// Leak detection is a debugging aid; it adds an extra allocation and
+// finalizer per frame, and hides the concrete type of the frame, so
+// it is off by default.
+type leakFrame struct {
+	frame.Frame
+	c        *Snapper
+	released int32
+}
+
+// trackLeak wraps the frame so that leaks can be reported.
+func (c *Snapper) trackLeak(f frame.Frame) frame.Frame {
+	lf := &leakFrame{Frame: f, c: c}
+	runtime.SetFinalizer(lf, func(lf *leakFrame) {
+		if atomic.LoadInt32(&lf.released) == 0 {
+			log.Printf("snapshot: frame garbage collected without Release (%d outstanding)",
+				atomic.LoadInt32(&lf.c.outstanding))
+			lf.Release()
+		}
+	})
+	return lf
+}
+
+// Release releases the wrapped frame.
+func (lf *leakFrame) Release() {
+	if atomic.CompareAndSwapInt32(&lf.released, 0, 1) {
+		lf.Frame.Release()
+	}
+}
+
+// Timestamp returns the capture time of the wrapped frame.
+func (lf *leakFrame) Timestamp() time.Time {
+	return frame.TimestampOf(lf.Frame)
+}
+
+// Sequence returns the sequence number of the wrapped frame.
+func (lf *leakFrame) Sequence() uint32 {
+	return frame.SequenceOf(lf.Frame)
+}
+
+// Raw returns the raw data of the wrapped frame.
+func (lf *leakFrame) Raw() []byte {
+	return frame.RawOf(lf.Frame)
+}
+
+// Fingerprint returns the fingerprint of the wrapped frame.
+func (lf *leakFrame) Fingerprint() uint64 {
+	return frame.FingerprintOf(lf.Frame)
+}
+
+// CopyToBuffer copies the data of the wrapped frame.
+func (lf *leakFrame) CopyToBuffer(dst []byte) (int, error) {
+	return frame.CopyToBuffer(lf.Frame, dst)
+}
+
+// Err returns the error from decoding the wrapped frame.
+func (lf *leakFrame) Err() error {
+	return frame.DecodeErr(lf.Frame)
+}
+
+// Tag returns the tag of the wrapped frame.
+func (lf *leakFrame) Tag() interface{} {
+	return frame.TagOf(lf.Frame)
+}
+
+// Framer returns the framer of the wrapped frame.
+func (lf *leakFrame) Framer() func([]byte, func()) (frame.Frame, error) {
+	return frame.FramerOf(lf.Frame)
+}
+
+// Orientation returns the orientation of the wrapped frame.
+func (lf *leakFrame) Orientation() frame.Orientation {
+	return frame.OrientationOf(lf.Frame)
+}
+
+// Outstanding returns the number of frames returned by Snap that
+// have not been released, including those being read by Snap.
+func (c *Snapper) Outstanding() int {
+	return int(atomic.LoadInt32(&c.outstanding))
+}
@@ -0,0 +1,105 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+func TestMaxOutstanding(t *testing.T) {
+	c := NewSnapper()
+	c.MaxOutstanding = 2
+	openFake(t, c, nil)
+	var held []frame.Frame
+	for i := 0; i < c.MaxOutstanding; i++ {
+		f, err := c.Snap()
+		if err != nil {
+			t.Fatal(err)
+		}
+		held = append(held, f)
+	}
+	if _, err := c.Snap(); !errors.Is(err, ErrTooManyOutstanding) {
+		t.Fatalf("Snap: got %v, want ErrTooManyOutstanding", err)
+	}
+	if _, _, err := c.TrySnap(); !errors.Is(err, ErrTooManyOutstanding) {
+		t.Fatalf("TrySnap: got %v, want ErrTooManyOutstanding", err)
+	}
+	if c.Outstanding() != c.MaxOutstanding {
+		t.Fatalf("Outstanding: got %d, want %d", c.Outstanding(), c.MaxOutstanding)
+	}
+	held[0].Release()
+	f, err := c.Snap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Release()
+	held[1].Release()
+	if c.Outstanding() != 0 {
+		t.Fatalf("Outstanding: got %d, want 0", c.Outstanding())
+	}
+}
+
+func TestMaxOutstandingConcurrent(t *testing.T) {
+	c := NewSnapper()
+	c.MaxOutstanding = 2
+	openFake(t, c, nil)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var held []frame.Frame
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f, err := c.Snap()
+			if errors.Is(err, ErrTooManyOutstanding) {
+				return
+			} else if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			held = append(held, f)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if len(held) != c.MaxOutstanding {
+		t.Fatalf("%d frames held, limit is %d", len(held), c.MaxOutstanding)
+	}
+	for _, f := range held {
+		f.Release()
+	}
+	if c.Outstanding() != 0 {
+		t.Fatalf("Outstanding: got %d, want 0", c.Outstanding())
+	}
+}
+
+func TestEachReleasesOnError(t *testing.T) {
+	c := NewSnapper()
+	openFake(t, c, nil)
+	stop := errors.New("stop")
+	calls := 0
+	err := c.Each(context.Background(), func(f frame.Frame) error {
+		calls++
+		if c.Outstanding() != 1 {
+			t.Errorf("Outstanding in fn: got %d, want 1", c.Outstanding())
+		}
+		if calls == 3 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("Each: got %v, want the error from fn", err)
+	}
+	if calls != 3 {
+		t.Fatalf("fn called %d times, want 3", calls)
+	}
+	// The frame passed to fn was released when fn returned the error.
+	if c.Outstanding() != 0 {
+		t.Fatalf("Outstanding: got %d, want 0", c.Outstanding())
+	}
+}
@@ -0,0 +1,100 @@
+package snapshot
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	"github.com/aamcrae/webcam"
+	"github.com/aamcrae/webcam/frame"
+)
+
+// applyLowLight configures the camera for low light capture: manual
+// exposure with long exposure and high gain, and power line anti-flicker
+// disabled so that the exposure is not limited to the mains period.
+// Controls that the camera does not have are skipped.
+func (c *Snapper) applyLowLight() error {
+	controls := c.cam.GetControls()
+	steps := []struct {
+		id    uint32
+		value int32
+		limit bool // The value is a target limited to the control range.
+	}{
+		// Exposure must be manual before the absolute exposure can be set.
+		{webcam.V4L2_CID_EXPOSURE_AUTO, webcam.V4L2_EXPOSURE_MANUAL, false},
+		{webcam.V4L2_CID_POWER_LINE_FREQUENCY, webcam.V4L2_CID_POWER_LINE_FREQUENCY_DISABLED, false},
+		{webcam.V4L2_CID_EXPOSURE_ABSOLUTE, c.LowLightExposure, true},
+		{webcam.V4L2_CID_GAIN, c.LowLightGain, true},
+	}
+	set := make(map[webcam.ControlID]int32)
+	for _, s := range steps {
+		id := webcam.ControlID(s.id)
+		ctrl, ok := controls[id]
+		if !ok || ctrl.ReadOnly {
+			continue
+		}
+		v := s.value
+		if s.limit {
+			if v == 0 || v > ctrl.Max {
+				v = ctrl.Max
+			} else if v < ctrl.Min {
+				v = ctrl.Min
+			}
+		}
+		if err := c.cam.SetControl(id, v); err != nil {
+			return fmt.Errorf("%s: %v", ctrl.Name, err)
+		}
+		set[id] = v
+	}
+	c.lowLight.Store(set)
+	return nil
+}
+
+// LowLightSettings returns the control values set by LowLightMode when
+// the camera was opened, or nil if LowLightMode was not set.
+func (c *Snapper) LowLightSettings() map[webcam.ControlID]int32 {
+	set, _ := c.lowLight.Load().(map[webcam.ControlID]int32)
+	if set == nil || !c.LowLightMode {
+		return nil
+	}
+	m := make(map[webcam.ControlID]int32, len(set))
+	for id, v := range set {
+		m[id] = v
+	}
+	return m
+}
+
+// SnapAveraged snaps n frames and returns their average, which reduces
+// the sensor noise of low light images at the cost of blurring motion.
+func (c *Snapper) SnapAveraged(n int) (*image.RGBA, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("illegal frame count %d", n)
+	}
+	var sum []uint32
+	var img *image.RGBA
+	for i := 0; i < n; i++ {
+		f, err := c.Snap()
+		if err != nil {
+			return nil, err
+		}
+		if img == nil {
+			img = image.NewRGBA(f.Bounds())
+			sum = make([]uint32, len(img.Pix))
+		}
+		if !f.Bounds().Eq(img.Rect) {
+			f.Release()
+			return nil, fmt.Errorf("frame size changed from %v to %v", img.Rect, f.Bounds())
+		}
+		if w, ok := f.(frame.RGBAWriter); !ok || w.WriteRGBA(img) != nil {
+			draw.Draw(img, img.Rect, f, img.Rect.Min, draw.Src)
+		}
+		f.Release()
+		for j, p := range img.Pix {
+			sum[j] += uint32(p)
+		}
+	}
+	for j, s := range sum {
+		img.Pix[j] = uint8((s + uint32(n)/2) / uint32(n))
+	}
+	return img, nil
+}
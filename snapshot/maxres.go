@@ -0,0 +1,80 @@
+package snapshot
+
+import (
+	"fmt"
+
+	"github.com/aamcrae/webcam"
+	"github.com/aamcrae/webcam/frame"
+)
+
+// MaxResolution opens the device and returns the largest frame size
+// (by area) supported for the format. For stepwise or continuous frame
+// sizes, the maximum width and height are used.
+func MaxResolution(device string, format frame.FourCC) (w, h int, err error) {
+	pf, err := frame.FourCCToPixelFormat(format)
+	if err != nil {
+		return 0, 0, err
+	}
+	cam, err := openCamera(device)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cam.Close()
+	if _, ok := cam.GetSupportedFormats()[pf]; !ok {
+		return 0, 0, fmt.Errorf("%s: unsupported format: %s", device, format)
+	}
+	w, h = maxFrameSize(cam.GetSupportedFrameSizes(pf))
+	if w == 0 {
+		return 0, 0, fmt.Errorf("%s: no frame sizes for format %s", device, format)
+	}
+	return w, h, nil
+}
+
+// DefaultResolution opens the device and returns the preferred frame size
+// for the format. This is the size reported by the driver before a format
+// has been set, if the format supports it, otherwise the largest size.
+func DefaultResolution(device string, format frame.FourCC) (w, h int, err error) {
+	pf, err := frame.FourCCToPixelFormat(format)
+	if err != nil {
+		return 0, 0, err
+	}
+	cam, err := openCamera(device)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cam.Close()
+	if _, ok := cam.GetSupportedFormats()[pf]; !ok {
+		return 0, 0, fmt.Errorf("%s: unsupported format: %s", device, format)
+	}
+	w, h = defaultFrameSize(cam, pf)
+	if w == 0 {
+		return 0, 0, fmt.Errorf("%s: no frame sizes for format %s", device, format)
+	}
+	return w, h, nil
+}
+
+// defaultFrameSize returns the driver's current frame size if it is
+// supported by the format, otherwise the largest supported size.
+func defaultFrameSize(cam camera, pf webcam.PixelFormat) (w, h int) {
+	sizes := cam.GetSupportedFrameSizes(pf)
+	if _, cw, ch, err := cam.GetImageFormat(); err == nil {
+		for _, fs := range sizes {
+			if Match(fs, int(cw), int(ch)) {
+				return int(cw), int(ch)
+			}
+		}
+	}
+	return maxFrameSize(sizes)
+}
+
+// maxFrameSize returns the largest size by area of the frame sizes.
+// Discrete sizes have equal minimum and maximum dimensions.
+func maxFrameSize(sizes []webcam.FrameSize) (w, h int) {
+	for _, fs := range sizes {
+		fw, fh := int(fs.MaxWidth), int(fs.MaxHeight)
+		if fw*fh > w*h {
+			w, h = fw, fh
+		}
+	}
+	return w, h
+}
@@ -0,0 +1,32 @@
+package snapshot
+
+import (
+	"github.com/aamcrae/webcam"
+	"github.com/aamcrae/webcam/frame"
+)
+
+// sensorOrientation returns the transform that corrects the sensor
+// mounting rotation reported by the camera. The rotation is the angle
+// counter-clockwise that the image must be rotated to be upright.
+// No transform is used if the rotation is unknown.
+func (c *Snapper) sensorOrientation() frame.Orientation {
+	rot, err := c.cam.GetControl(webcam.ControlID(webcam.V4L2_CID_CAMERA_SENSOR_ROTATION))
+	if err != nil {
+		return frame.OrientNormal
+	}
+	return rotationOrientation(rot)
+}
+
+// rotationOrientation returns the orientation for a counter-clockwise
+// rotation in degrees.
+func rotationOrientation(degrees int32) frame.Orientation {
+	switch (degrees%360 + 360) % 360 {
+	case 90:
+		return frame.OrientRotate270
+	case 180:
+		return frame.OrientRotate180
+	case 270:
+		return frame.OrientRotate90
+	}
+	return frame.OrientNormal
+}
@@ -0,0 +1,48 @@
+package snapshot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// ParseResolution parses a resolution of the form "WxH", e.g "1280x720".
+func ParseResolution(s string) (w, h int, err error) {
+	p := strings.Split(strings.ToLower(strings.TrimSpace(s)), "x")
+	if len(p) != 2 {
+		return 0, 0, fmt.Errorf("%s: illegal resolution", s)
+	}
+	if w, err = strconv.Atoi(p[0]); err != nil {
+		return 0, 0, fmt.Errorf("%s: illegal width: %v", s, err)
+	}
+	if h, err = strconv.Atoi(p[1]); err != nil {
+		return 0, 0, fmt.Errorf("%s: illegal height: %v", s, err)
+	}
+	if w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf("%s: resolution must be positive", s)
+	}
+	return w, h, nil
+}
+
+// OpenString opens the camera using a format and resolution given as
+// strings, e.g from a configuration file or flags.
+// An empty format selects a format automatically, and an empty
+// resolution selects the default resolution for the format.
+func (c *Snapper) OpenString(device, formatStr, resString string) error {
+	var w, h int
+	if strings.TrimSpace(resString) != "" {
+		var err error
+		if w, h, err = ParseResolution(resString); err != nil {
+			return err
+		}
+	}
+	format := frame.FourCC(formatStr)
+	if len(format) != 0 {
+		if _, err := frame.FourCCToPixelFormat(format); err != nil {
+			return err
+		}
+	}
+	return c.Open(device, format, w, h)
+}
@@ -0,0 +1,93 @@
+package snapshot
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/aamcrae/webcam"
+)
+
+// ControlState holds the values of the writable controls of a camera,
+// as saved by SaveControls. It can be stored as JSON, so that presets
+// can be kept on disk and restored in a later run.
+type ControlState struct {
+	Controls []ControlValue `json:"controls"`
+}
+
+// ControlValue is the value of a single control. The name is
+// recorded to make saved state readable, and is not used on restore,
+// where controls are matched by ID.
+type ControlValue struct {
+	ID    webcam.ControlID `json:"id"`
+	Name  string           `json:"name"`
+	Value int32            `json:"value"`
+}
+
+// SaveControls reads the current values of all the writable controls.
+func (c *Snapper) SaveControls() (ControlState, error) {
+	cam, err := c.openCam()
+	if err != nil {
+		return ControlState{}, err
+	}
+	var st ControlState
+	for id, ctrl := range cam.GetControls() {
+		if ctrl.ReadOnly {
+			continue
+		}
+		v, err := cam.GetControl(id)
+		if err != nil {
+			return ControlState{}, err
+		}
+		st.Controls = append(st.Controls, ControlValue{ID: id, Name: ctrl.Name, Value: v})
+	}
+	sortControls(st.Controls)
+	return st, nil
+}
+
+// RestoreControls sets the controls to the saved values.
+// Automatic mode controls (e.g auto exposure) are set first, so that
+// the manual controls they govern are restored afterwards. Controls
+// that the camera does not have are skipped, as are controls that the
+// driver refuses to set while an automatic mode is on.
+func (c *Snapper) RestoreControls(st ControlState) error {
+	cam, err := c.openCam()
+	if err != nil {
+		return err
+	}
+	controls := cam.GetControls()
+	var values []ControlValue
+	for _, v := range st.Controls {
+		if ctrl, ok := controls[v.ID]; ok && !ctrl.ReadOnly {
+			values = append(values, ControlValue{ID: v.ID, Name: ctrl.Name, Value: v.Value})
+		}
+	}
+	sortControls(values)
+	for _, v := range values {
+		if err := cam.SetControl(v.ID, v.Value); err != nil && !errors.Is(err, syscall.EACCES) {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortControls orders the controls with automatic mode controls first,
+// and otherwise by ID.
+func sortControls(values []ControlValue) {
+	sort.Slice(values, func(i, j int) bool {
+		ai, aj := isAutoControl(values[i]), isAutoControl(values[j])
+		if ai != aj {
+			return ai
+		}
+		return values[i].ID < values[j].ID
+	})
+}
+
+func isAutoControl(v ControlValue) bool {
+	switch uint32(v.ID) {
+	case webcam.V4L2_CID_AUTO_WHITE_BALANCE, webcam.V4L2_CID_EXPOSURE_AUTO:
+		return true
+	}
+	return strings.Contains(strings.ToLower(v.Name), "auto")
+}
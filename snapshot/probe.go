@@ -0,0 +1,181 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aamcrae/webcam"
+	"github.com/aamcrae/webcam/frame"
+)
+
+// ProbeInfo describes the capabilities of a device. Formats, sizes
+// and controls are sorted so that the JSON encoding is stable.
+type ProbeInfo struct {
+	Device       string         `json:"device"`
+	Driver       string         `json:"driver"`
+	Card         string         `json:"card"`
+	BusInfo      string         `json:"bus_info"`
+	Version      uint32         `json:"version"`
+	Capabilities uint32         `json:"capabilities"`
+	DeviceCaps   uint32         `json:"device_caps"`
+	Formats      []ProbeFormat  `json:"formats"`
+	Controls     []ProbeControl `json:"controls"`
+}
+
+// ProbeFormat is a pixel format and the frame sizes supported for it.
+type ProbeFormat struct {
+	FourCC      frame.FourCC `json:"fourcc"`
+	Description string       `json:"description"`
+	// Framer is true if frames of this format can be decoded.
+	Framer bool        `json:"framer"`
+	Sizes  []ProbeSize `json:"sizes"`
+}
+
+// ProbeSize is a frame size. Discrete sizes have only Width and Height,
+// whereas stepwise sizes also have the range and step. The frame rates
+// are those of the maximum size.
+type ProbeSize struct {
+	Width      uint32    `json:"width"`
+	Height     uint32    `json:"height"`
+	MinWidth   uint32    `json:"min_width,omitempty"`
+	MinHeight  uint32    `json:"min_height,omitempty"`
+	StepWidth  uint32    `json:"step_width,omitempty"`
+	StepHeight uint32    `json:"step_height,omitempty"`
+	Rates      []float64 `json:"rates,omitempty"`
+}
+
+// ProbeControl is a camera control and its range.
+type ProbeControl struct {
+	ID      webcam.ControlID `json:"id"`
+	Name    string           `json:"name"`
+	Min     int32            `json:"min"`
+	Max     int32            `json:"max"`
+	Step    int32            `json:"step"`
+	Default int32            `json:"default"`
+	Menu    []ProbeMenuItem  `json:"menu,omitempty"`
+}
+
+// ProbeMenuItem is one item of a menu control.
+type ProbeMenuItem struct {
+	Value int32  `json:"value"`
+	Name  string `json:"name"`
+}
+
+// Probe opens the device and reads its capabilities, formats, frame sizes,
+// frame rates and controls. The device is closed before Probe returns.
+func Probe(device string) (*ProbeInfo, error) {
+	cam, err := openCamera(device)
+	if err != nil {
+		return nil, err
+	}
+	defer cam.Close()
+	caps, err := cam.GetCapability()
+	if err != nil {
+		return nil, err
+	}
+	p := &ProbeInfo{Device: device, Driver: caps.Driver, Card: caps.Card, BusInfo: caps.BusInfo,
+		Version: caps.Version, Capabilities: caps.Capabilities, DeviceCaps: caps.DeviceCaps}
+	for pf, desc := range cam.GetSupportedFormats() {
+		f := ProbeFormat{FourCC: frame.PixelFormatToFourCC(pf), Description: desc}
+		f.Framer = frame.HasFramer(f.FourCC)
+		for _, fs := range cam.GetSupportedFrameSizes(pf) {
+			s := ProbeSize{Width: fs.MaxWidth, Height: fs.MaxHeight}
+			if fs.StepWidth != 0 || fs.StepHeight != 0 {
+				s.MinWidth, s.MinHeight = fs.MinWidth, fs.MinHeight
+				s.StepWidth, s.StepHeight = fs.StepWidth, fs.StepHeight
+			}
+			s.Rates = cam.GetFrameRates(pf, fs.MaxWidth, fs.MaxHeight)
+			f.Sizes = append(f.Sizes, s)
+		}
+		sort.Slice(f.Sizes, func(i, j int) bool {
+			a, b := f.Sizes[i], f.Sizes[j]
+			if a.Width*a.Height != b.Width*b.Height {
+				return a.Width*a.Height < b.Width*b.Height
+			}
+			return a.Width < b.Width
+		})
+		p.Formats = append(p.Formats, f)
+	}
+	sort.Slice(p.Formats, func(i, j int) bool { return p.Formats[i].FourCC < p.Formats[j].FourCC })
+	for id, c := range cam.GetControls() {
+		pc := ProbeControl{ID: id, Name: c.Name, Min: c.Min, Max: c.Max, Step: c.Step, Default: c.Default}
+		if c.Menu {
+			menu, _ := cam.GetControlMenu(id)
+			for v, name := range menu {
+				pc.Menu = append(pc.Menu, ProbeMenuItem{v, name})
+			}
+			sort.Slice(pc.Menu, func(i, j int) bool { return pc.Menu[i].Value < pc.Menu[j].Value })
+		}
+		p.Controls = append(p.Controls, pc)
+	}
+	sort.Slice(p.Controls, func(i, j int) bool { return p.Controls[i].ID < p.Controls[j].ID })
+	return p, nil
+}
+
+// JSON returns the probe information as indented JSON, suitable
+// for including in a bug report.
+func (p *ProbeInfo) JSON() ([]byte, error) {
+	return json.MarshalIndent(p, "", "  ")
+}
+
+// CapabilityTable probes the device and returns a table of its formats,
+// with the frame sizes of each format and their maximum frame rate.
+func CapabilityTable(device string) (string, error) {
+	p, err := Probe(device)
+	if err != nil {
+		return "", err
+	}
+	return p.Table(), nil
+}
+
+// Table returns the formats and frame sizes as a human readable table,
+// aligned in columns. Formats that cannot be decoded are marked with '*'.
+func (p *ProbeInfo) Table() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s (%s, %s)\n", p.Device, p.Card, p.Driver, p.BusInfo)
+	tw := tabwriter.NewWriter(&b, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "FORMAT\tDESCRIPTION\tSIZE\tMAX FPS")
+	for _, f := range p.Formats {
+		name := string(f.FourCC)
+		if !f.Framer {
+			name += "*"
+		}
+		desc := f.Description
+		if len(f.Sizes) == 0 {
+			fmt.Fprintf(tw, "%s\t%s\t-\t-\n", name, desc)
+		}
+		for _, s := range f.Sizes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", name, desc, s.size(), s.maxRate())
+			// Only show the format on its first line.
+			name, desc = "", ""
+		}
+	}
+	tw.Flush()
+	return b.String()
+}
+
+// size returns the frame size as a string, including the range
+// of stepwise sizes.
+func (s ProbeSize) size() string {
+	if s.StepWidth == 0 && s.StepHeight == 0 {
+		return fmt.Sprintf("%dx%d", s.Width, s.Height)
+	}
+	return fmt.Sprintf("%dx%d-%dx%d step %dx%d", s.MinWidth, s.MinHeight, s.Width, s.Height, s.StepWidth, s.StepHeight)
+}
+
+// maxRate returns the highest frame rate as a string, or "-" if unknown.
+func (s ProbeSize) maxRate() string {
+	if len(s.Rates) == 0 {
+		return "-"
+	}
+	max := s.Rates[0]
+	for _, r := range s.Rates[1:] {
+		if r > max {
+			max = r
+		}
+	}
+	return fmt.Sprintf("%.4g", max)
+}
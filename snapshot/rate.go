@@ -0,0 +1,52 @@
+package snapshot
+
+import (
+	"time"
+)
+
+// SetConsumerRate limits Snap to returning at most fps frames per second,
+// regardless of the rate the camera is delivering. Frames arriving between
+// deliveries are released, so the frame returned is always the most recent.
+// An fps of zero or less removes the limit.
+func (c *Snapper) SetConsumerRate(fps float64) {
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+	if fps <= 0 {
+		c.rate = 0
+		return
+	}
+	c.rate = time.Duration(float64(time.Second) / fps)
+}
+
+// throttle waits until the next frame is due under the consumer rate.
+// The delivery time is reserved before waiting, so that the lock is not
+// held while sleeping, and concurrent callers are spaced by the rate.
+func (c *Snapper) throttle() {
+	c.rateMu.Lock()
+	if c.rate == 0 {
+		c.rateMu.Unlock()
+		return
+	}
+	now := time.Now()
+	next := c.lastSnap.Add(c.rate)
+	if next.Before(now) {
+		next = now
+	}
+	c.lastSnap = next
+	c.rateMu.Unlock()
+	time.Sleep(next.Sub(now))
+}
+
+// due reports whether a frame may be returned now under the consumer rate.
+func (c *Snapper) due() bool {
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+	return c.rate == 0 || !time.Now().Before(c.lastSnap.Add(c.rate))
+}
+
+// delivered records that a frame has been returned.
+func (c *Snapper) delivered() {
+	c.rateMu.Lock()
+	c.lastSnap = time.Now()
+	c.rateMu.Unlock()
+}
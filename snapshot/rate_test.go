@@ -0,0 +1,62 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConsumerRateInterval(t *testing.T) {
+	c := NewSnapper()
+	openFake(t, c, nil)
+	const interval = 20 * time.Millisecond
+	c.SetConsumerRate(float64(time.Second / interval))
+	var last time.Time
+	for i := 0; i < 5; i++ {
+		f, err := c.Snap()
+		if err != nil {
+			t.Fatal(err)
+		}
+		now := time.Now()
+		f.Release()
+		// Allow for the timer firing slightly early.
+		if i > 0 && now.Sub(last) < interval-2*time.Millisecond {
+			t.Fatalf("frame %d delivered %v after the last, want at least %v", i, now.Sub(last), interval)
+		}
+		last = now
+	}
+}
+
+func TestConsumerRateUnlocked(t *testing.T) {
+	c := NewSnapper()
+	openFake(t, c, nil)
+	c.SetConsumerRate(4)
+	f, err := c.Snap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Release()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if f, err := c.Snap(); err == nil {
+			f.Release()
+		} else {
+			t.Error(err)
+		}
+	}()
+	// While Snap waits for the next frame to be due, TrySnap and
+	// SetConsumerRate do not wait for it.
+	time.Sleep(20 * time.Millisecond)
+	start := time.Now()
+	if f, ok, err := c.TrySnap(); err != nil {
+		t.Fatal(err)
+	} else if ok {
+		f.Release()
+		t.Fatal("TrySnap returned a frame before it was due")
+	}
+	c.SetConsumerRate(0)
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Fatalf("TrySnap and SetConsumerRate took %v while Snap was waiting", d)
+	}
+	<-done
+}
@@ -0,0 +1,111 @@
+package snapshot
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// Magic number at the start of each raw frame written by DumpRaw.
+const rawMagic = "WRAW"
+
+// Upper limit on the size of a raw frame, to guard against corrupt files.
+const maxRawSize = 256 << 20
+
+// RawFrame is a frame as read from the camera, along with the layout
+// needed to decode it.
+type RawFrame struct {
+	Format    frame.FourCC
+	Width     int
+	Height    int
+	Stride    int
+	Sequence  uint32
+	Timestamp time.Time
+	Data      []byte
+}
+
+// rawHeader is the fixed size header written before the frame data.
+type rawHeader struct {
+	Magic     [4]byte
+	Format    [4]byte
+	Width     uint32
+	Height    uint32
+	Stride    uint32
+	Sequence  uint32
+	Timestamp int64 // Unix nanoseconds, or 0 if unknown.
+	Size      uint32
+}
+
+// DumpRaw writes the undecoded frame data to w, with a header recording
+// the format and layout of the frame, so that it can be read back with
+// ReadRaw and decoded using the same framer.
+func (c *Snapper) DumpRaw(w io.Writer, f frame.Frame) error {
+	raw := frame.RawOf(f)
+	c.stateMu.Lock()
+	h := rawHeader{
+		Width:    uint32(c.width),
+		Height:   uint32(c.height),
+		Stride:   uint32(c.stride),
+		Sequence: frame.SequenceOf(f),
+		Size:     uint32(len(raw)),
+	}
+	copy(h.Format[:], c.framerFormat)
+	c.stateMu.Unlock()
+	copy(h.Magic[:], rawMagic)
+	if t := frame.TimestampOf(f); !t.IsZero() {
+		h.Timestamp = t.UnixNano()
+	}
+	if err := binary.Write(w, binary.BigEndian, &h); err != nil {
+		return err
+	}
+	_, err := w.Write(raw)
+	return err
+}
+
+// ReadRaw reads a frame written by DumpRaw. io.EOF is returned
+// if there are no more frames.
+func ReadRaw(r io.Reader) (*RawFrame, error) {
+	var h rawHeader
+	if err := binary.Read(r, binary.BigEndian, &h); err != nil {
+		return nil, err
+	}
+	if string(h.Magic[:]) != rawMagic {
+		return nil, errors.New("not a raw frame")
+	}
+	if h.Size > maxRawSize {
+		return nil, fmt.Errorf("raw frame too large (%d bytes)", h.Size)
+	}
+	rf := &RawFrame{
+		Format:   frame.FourCC(h.Format[:]),
+		Width:    int(h.Width),
+		Height:   int(h.Height),
+		Stride:   int(h.Stride),
+		Sequence: h.Sequence,
+		Data:     make([]byte, h.Size),
+	}
+	if h.Timestamp != 0 {
+		rf.Timestamp = time.Unix(0, h.Timestamp)
+	}
+	if _, err := io.ReadFull(r, rf.Data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return rf, nil
+}
+
+// Frame decodes the raw frame using the framer for its format.
+// Any options are applied as they are by Snapper.
+func (rf *RawFrame) Frame(opts ...frame.Option) (frame.Frame, error) {
+	opts = append([]frame.Option{frame.WithTimestamp(rf.Timestamp), frame.WithSequence(rf.Sequence)}, opts...)
+	framer, err := frame.GetFramer(rf.Format, rf.Width, rf.Height, rf.Stride, len(rf.Data), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return framer(rf.Data, nil)
+}
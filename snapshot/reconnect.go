@@ -0,0 +1,216 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// Number of errors held for Errors before further errors are discarded.
+const errorQueue = 8
+
+const defaultReconnectInterval = time.Second
+
+// Number of consecutive transient errors tolerated before capture stops,
+// and the delay before each is retried.
+const (
+	maxTransientErrors = 10
+	transientDelay     = 10 * time.Millisecond
+)
+
+// ErrDeviceGone is reported when the camera can no longer be read,
+// typically because it has been unplugged.
+var ErrDeviceGone = errors.New("device gone")
+
+// ErrStalled is reported when no frame is read from the camera within
+// FirstFrameTimeout or FrameTimeout.
+var ErrStalled = errors.New("camera stalled")
+
+// Errors returns a channel on which errors that stop capture are reported,
+// including ErrDeviceGone and failed reconnection attempts.
+// Errors are discarded if the channel is not read.
+func (c *Snapper) Errors() <-chan error {
+	return c.errs
+}
+
+// captureError wraps errors indicating that the device has gone away
+// so that they match ErrDeviceGone. Other errors are returned unchanged.
+func captureError(err error) error {
+	if errors.Is(err, syscall.ENODEV) || errors.Is(err, syscall.ENXIO) || errors.Is(err, syscall.EIO) {
+		return fmt.Errorf("%w: %v", ErrDeviceGone, err)
+	}
+	return err
+}
+
+// transient returns true if the error is a temporary failure to read
+// a frame (e.g no buffer was ready despite the wait succeeding), so
+// that capture should retry rather than stop.
+func transient(err error) bool {
+	return errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR)
+}
+
+// backoff waits briefly before a transient error is retried, so that
+// the retries span more than an instant. It returns false if capture
+// is stopped while waiting.
+func (c *Snapper) backoff() bool {
+	t := time.NewTimer(transientDelay)
+	defer t.Stop()
+	select {
+	case <-c.stop:
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// report records the error and sends it to the error channel.
+func (c *Snapper) report(err error) {
+	c.setErr(err)
+	select {
+	case c.errs <- err:
+	default:
+	}
+}
+
+func (c *Snapper) setErr(err error) {
+	c.errMu.Lock()
+	c.err = err
+	c.errMu.Unlock()
+}
+
+// streamErr returns the error to report once the stream has closed.
+// The stream closes without an error only when the Snapper is closed.
+func (c *Snapper) streamErr() error {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	return ErrNotOpen
+}
+
+// run captures frames until stopped. If capture fails because the device
+// has gone or has stalled and AutoReconnect is set, the device is reopened.
+// The stream is closed before done, so that shutdown can release
+// the remaining frames.
+func (c *Snapper) run() {
+	defer close(c.done)
+	for {
+		err := c.safeCapture()
+		if err == nil {
+			close(c.stream)
+			return
+		}
+		atomic.StoreInt32(&c.stats.streaming, 0)
+		c.report(err)
+		if !c.AutoReconnect || !(errors.Is(err, ErrDeviceGone) || errors.Is(err, ErrStalled)) || !c.reconnect() {
+			close(c.stream)
+			return
+		}
+		atomic.StoreInt32(&c.stats.streaming, 1)
+	}
+}
+
+// reconnect attempts to reopen the device until it succeeds or
+// the Snapper is closed, in which case false is returned.
+// The frame waiting in the stream is released, and the old camera is
+// stopped once the frames still held from it are released, since
+// stopping it unmaps their buffers. Until a new camera has been
+// started, the old camera is kept so that Close has a camera to shut down.
+func (c *Snapper) reconnect() bool {
+	interval := c.ReconnectInterval
+	if interval <= 0 {
+		interval = defaultReconnectInterval
+	}
+	c.discardStale()
+	old := c.getCam()
+	old.retire()
+	for {
+		select {
+		case <-c.stop:
+			return false
+		case <-time.After(interval):
+		}
+		if err := c.restart(old); err == ErrNotOpen {
+			return false
+		} else if err != nil {
+			c.report(fmt.Errorf("%s: reconnect: %w", c.device, err))
+			continue
+		}
+		c.setErr(nil)
+		return true
+	}
+}
+
+// restart starts a new camera with the settings used by Open,
+// restoring the old camera if it fails. ErrNotOpen is returned if the
+// Snapper is being closed. stateMu is held, since start changes the
+// settings that are read by other methods.
+func (c *Snapper) restart(old *streamCam) error {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if c.stopped() {
+		return ErrNotOpen
+	}
+	err := c.start(c.device, c.format, c.openW, c.openH)
+	if err != nil {
+		if cam := c.getCam(); cam != old {
+			cam.shutdown()
+			c.setCam(old)
+		}
+	}
+	return err
+}
+
+// OpenWait opens the camera as Open does, retrying while the device is
+// not yet available (e.g it does not exist yet, or is busy or not yet
+// accessible during boot) until it is opened or the context is done.
+// Retries are made every ReconnectInterval. Other errors are returned
+// immediately.
+func (c *Snapper) OpenWait(ctx context.Context, device string, format frame.FourCC, w, h int) error {
+	interval := c.ReconnectInterval
+	if interval <= 0 {
+		interval = defaultReconnectInterval
+	}
+	for {
+		err := c.Open(device, format, w, h)
+		if err == nil || !notReady(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w (%v)", device, ctx.Err(), err)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// notReady returns true if the error indicates that the device may
+// become available later.
+func notReady(err error) bool {
+	for _, e := range []error{syscall.ENOENT, syscall.ENODEV, syscall.ENXIO, syscall.EBUSY, syscall.EACCES} {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// safeCapture runs capture, converting a panic into an error
+// if RecoverPanics is set, so that a failure in the capture goroutine
+// shuts down the stream rather than the process.
+func (c *Snapper) safeCapture() (err error) {
+	if c.RecoverPanics {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("capture panic: %v", r)
+			}
+		}()
+	}
+	return c.capture()
+}
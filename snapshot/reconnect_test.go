@@ -0,0 +1,173 @@
+package snapshot
+
+import (
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+func TestReconnectKeepsHeldFrames(t *testing.T) {
+	c := NewSnapper()
+	c.AutoReconnect = true
+	c.ReconnectInterval = time.Millisecond
+	n := 0
+	d := openFake(t, c, func(cam *fakeCamera) {
+		if n++; n == 1 {
+			cam.run(fakeStep{}, fakeStep{}, fakeStep{}, fakeStep{wait: syscall.ENODEV})
+		}
+	})
+	held, err := c.Snap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := held.At(0, 0)
+	old := d.last()
+
+	// Use the camera while it is replaced.
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			c.GetControl(1)
+			c.Inputs()
+		}
+	}()
+	// Read frames until one is captured by the new camera.
+	for {
+		f, err := c.Snap()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cams := len(d.opened())
+		f.Release()
+		if cams > 1 {
+			break
+		}
+	}
+	close(stop)
+	wg.Wait()
+	if err := <-c.Errors(); !errors.Is(err, ErrDeviceGone) {
+		t.Fatalf("Errors: got %v, want ErrDeviceGone", err)
+	}
+	if old.isClosed() {
+		t.Fatal("old camera closed while a frame is held")
+	}
+	if got := held.At(0, 0); got != want {
+		t.Fatalf("held frame changed from %v to %v", want, got)
+	}
+	held.Release()
+	if !old.isClosed() {
+		t.Fatal("old camera not closed once its frames were released")
+	}
+	if c.Outstanding() != 0 {
+		t.Fatalf("Outstanding: got %d, want 0", c.Outstanding())
+	}
+}
+
+func TestReconnectRetries(t *testing.T) {
+	c := NewSnapper()
+	c.AutoReconnect = true
+	c.ReconnectInterval = time.Millisecond
+	var d *fakeDevice
+	d = useFake(t, func() *fakeCamera {
+		cam := newFakeCamera("YUYV", 4, 2)
+		if len(d.cams) == 0 {
+			cam.run(fakeStep{wait: syscall.ENODEV})
+			// The device is missing for the first attempts to reconnect.
+			d.openErr = syscall.ENOENT
+		}
+		return cam
+	})
+	if err := c.Open("/dev/fake", "YUYV", 4, 2); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := <-c.Errors(); !errors.Is(err, ErrDeviceGone) {
+		t.Fatalf("Errors: got %v, want ErrDeviceGone", err)
+	}
+	if err := <-c.Errors(); !errors.Is(err, syscall.ENOENT) {
+		t.Fatalf("Errors: got %v, want a failed reconnect", err)
+	}
+	d.mu.Lock()
+	d.openErr = nil
+	d.mu.Unlock()
+	f, err := c.Snap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frame.SequenceOf(f) != 1 {
+		t.Fatalf("frame %d is not from the new camera", frame.SequenceOf(f))
+	}
+	f.Release()
+	if !d.opened()[0].isClosed() {
+		t.Fatal("old camera not closed")
+	}
+}
+
+func TestReconnectClose(t *testing.T) {
+	c := NewSnapper()
+	c.AutoReconnect = true
+	c.ReconnectInterval = time.Hour
+	d := openFake(t, c, func(cam *fakeCamera) {
+		cam.run(fakeStep{wait: syscall.ENODEV})
+	})
+	<-c.Errors()
+	start := time.Now()
+	c.Close()
+	if time.Since(start) > time.Second {
+		t.Fatalf("Close took %v while reconnecting", time.Since(start))
+	}
+	if cams := d.opened(); len(cams) != 1 || !cams[0].isClosed() {
+		t.Fatal("camera not closed")
+	}
+}
+
+func TestTransientRetried(t *testing.T) {
+	c := NewSnapper()
+	eagain := fakeStep{get: syscall.EAGAIN}
+	start := time.Now()
+	openFake(t, c, func(cam *fakeCamera) {
+		cam.run(eagain, fakeStep{wait: syscall.EINTR}, eagain)
+	})
+	f, err := c.Snap()
+	if err != nil {
+		t.Fatalf("Snap after transient errors: %v", err)
+	}
+	f.Release()
+	if d := time.Since(start); d < 3*transientDelay {
+		t.Fatalf("3 retries took %v, want at least %v", d, 3*transientDelay)
+	}
+	select {
+	case err := <-c.Errors():
+		t.Fatalf("transient error reported: %v", err)
+	default:
+	}
+}
+
+func TestTransientLimit(t *testing.T) {
+	c := NewSnapper()
+	openFake(t, c, func(cam *fakeCamera) {
+		var steps []fakeStep
+		for i := 0; i <= maxTransientErrors; i++ {
+			steps = append(steps, fakeStep{get: syscall.EAGAIN})
+		}
+		cam.run(steps...)
+	})
+	if _, err := c.Snap(); !errors.Is(err, syscall.EAGAIN) {
+		t.Fatalf("Snap: got %v, want EAGAIN", err)
+	}
+	if err := <-c.Errors(); !errors.Is(err, syscall.EAGAIN) {
+		t.Fatalf("Errors: got %v, want EAGAIN", err)
+	}
+}
@@ -0,0 +1,145 @@
+package snapshot
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// Suffix used for a segment while it is being written.
+const partSuffix = ".part"
+
+// Recorder writes frames from a Snapper as MJPEG (concatenated JPEG)
+// files, starting a new segment file after a period of time or when
+// the segment reaches a maximum size.
+// Segments are named by the time of their first frame, e.g
+// 20060102-150405.000.mjpeg. A segment is written with a .part suffix
+// which is removed when the segment is complete.
+type Recorder struct {
+	Segment  time.Duration // Maximum duration of a segment (0 for no limit)
+	MaxBytes int64         // Maximum size of a segment (0 for no limit)
+	Quality  int           // JPEG quality used when frames are not MJPEG
+
+	snapper *Snapper
+	dir     string
+	file    *os.File
+	w       *bufio.Writer
+	name    string
+	start   time.Time
+	written int64
+}
+
+// NewRecorder creates a Recorder that writes segments to dir.
+func NewRecorder(s *Snapper, dir string, segment time.Duration, maxBytes int64) *Recorder {
+	return &Recorder{Segment: segment, MaxBytes: maxBytes, Quality: jpeg.DefaultQuality, snapper: s, dir: dir}
+}
+
+// Run records frames until the context is cancelled, when the current
+// segment is finalised and nil is returned. If the camera format is MJPEG
+// or JPEG, the frames are written without re-encoding, otherwise each
+// frame is encoded as a JPEG.
+func (r *Recorder) Run(ctx context.Context) error {
+	passthrough := isJPEG(r.snapper.Format())
+	for {
+		select {
+		case <-ctx.Done():
+			return r.finish()
+		default:
+		}
+		f, err := r.snapper.Snap()
+		if err != nil {
+			r.finish()
+			return err
+		}
+		now := time.Now()
+		if r.file != nil && r.full(now) {
+			if err := r.finish(); err != nil {
+				f.Release()
+				return err
+			}
+		}
+		if r.file == nil {
+			if err := r.create(now); err != nil {
+				f.Release()
+				return err
+			}
+		}
+		err = writeMJPEG(&countWriter{w: r.w, n: &r.written}, f, passthrough, r.Quality)
+		f.Release()
+		if err != nil {
+			r.finish()
+			return err
+		}
+	}
+}
+
+// isJPEG returns true if frames in the format are already JPEG encoded.
+func isJPEG(format frame.FourCC) bool {
+	return format == "MJPG" || format == "JPEG"
+}
+
+// writeMJPEG writes the frame as a JPEG, re-encoding it unless passthrough
+// is set, in which case the frame is already JPEG.
+func writeMJPEG(w io.Writer, f frame.Frame, passthrough bool, quality int) error {
+	if passthrough {
+		_, err := w.Write(frame.RawOf(f))
+		return err
+	}
+	return jpeg.Encode(w, f, &jpeg.Options{Quality: quality})
+}
+
+// countWriter counts the bytes written.
+type countWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.n += int64(n)
+	return n, err
+}
+
+// full returns true if the current segment should be finished.
+func (r *Recorder) full(now time.Time) bool {
+	return (r.Segment > 0 && now.Sub(r.start) >= r.Segment) ||
+		(r.MaxBytes > 0 && r.written >= r.MaxBytes)
+}
+
+// create starts a new segment.
+func (r *Recorder) create(now time.Time) error {
+	r.name = filepath.Join(r.dir, now.Format("20060102-150405.000")+".mjpeg")
+	f, err := os.Create(r.name + partSuffix)
+	if err != nil {
+		return err
+	}
+	r.file = f
+	r.w = bufio.NewWriter(f)
+	r.start = now
+	r.written = 0
+	return nil
+}
+
+// finish flushes and closes the current segment, and renames it
+// to its final name.
+func (r *Recorder) finish() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.w.Flush()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.file = nil
+	if err != nil {
+		return fmt.Errorf("%s: %v", r.name, err)
+	}
+	return os.Rename(r.name+partSuffix, r.name)
+}
@@ -0,0 +1,112 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// Capturer is a source of frames. It is implemented by Snapper, and by
+// ReplaySnapper so that pipelines can be run without a camera.
+type Capturer interface {
+	Snap() (frame.Frame, error)
+	Close()
+}
+
+var _ Capturer = (*Snapper)(nil)
+var _ Capturer = (*ReplaySnapper)(nil)
+
+// ReplaySnapper serves frames recorded with DumpRaw, so that a pipeline
+// can be tested deterministically. Each file with a .raw extension in
+// the directory holds one or more frames, and the files are read in
+// name order.
+type ReplaySnapper struct {
+	Loop    bool           // Restart from the first frame after the last.
+	Options []frame.Option // Options applied to each frame, as for Snapper.
+	SwapRB  bool           // Swap the red and blue channels, as for Snapper.
+
+	mu     sync.Mutex
+	files  []string
+	next   int // Index of the next file to open.
+	cur    *os.File
+	closed bool
+}
+
+// NewReplaySnapper creates a ReplaySnapper serving the frames in dir.
+func NewReplaySnapper(dir string) (*ReplaySnapper, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.raw"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s: no raw frames", dir)
+	}
+	sort.Strings(files)
+	return &ReplaySnapper{files: files}, nil
+}
+
+// Snap returns the next recorded frame. Once all the frames have been
+// served, io.EOF is returned unless Loop is set.
+func (r *ReplaySnapper) Snap() (frame.Frame, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, ErrNotOpen
+	}
+	rf, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+	opts := r.Options
+	if r.SwapRB {
+		opts = append(opts[:len(opts):len(opts)], frame.SwapRB())
+	}
+	return rf.Frame(opts...)
+}
+
+// read returns the next raw frame, moving to the next file as required.
+func (r *ReplaySnapper) read() (*RawFrame, error) {
+	for looped := false; ; {
+		if r.cur == nil {
+			if r.next == len(r.files) {
+				if !r.Loop || looped {
+					return nil, io.EOF
+				}
+				// Guard against looping forever when the files are empty.
+				r.next, looped = 0, true
+			}
+			f, err := os.Open(r.files[r.next])
+			if err != nil {
+				return nil, err
+			}
+			r.cur = f
+			r.next++
+		}
+		rf, err := ReadRaw(r.cur)
+		if err == nil {
+			return rf, nil
+		}
+		name := r.cur.Name()
+		r.cur.Close()
+		r.cur = nil
+		if err != io.EOF {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+// Close stops the replay. Further calls to Snap return ErrNotOpen.
+func (r *ReplaySnapper) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cur != nil {
+		r.cur.Close()
+		r.cur = nil
+	}
+	r.closed = true
+}
@@ -0,0 +1,180 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const defaultRingChunk = time.Second
+
+// RingRecorder continuously records frames to a ring of short MJPEG files
+// on disk holding the most recent frames, as a dashcam does. When Trigger
+// is called, a clip is saved containing the frames from Pre before the
+// trigger until Post after it.
+// Ring files are written to a "ring" subdirectory, and clips are built
+// from whole ring files, so a clip may start and end up to Chunk earlier
+// and later than requested.
+type RingRecorder struct {
+	Pre     time.Duration // Duration of frames kept before a trigger
+	Post    time.Duration // Duration of frames saved after a trigger
+	Chunk   time.Duration // Duration of each ring file
+	Quality int           // JPEG quality used when frames are not MJPEG
+
+	snapper *Snapper
+	dir     string
+	chunks  []ringSpan // Completed ring files, oldest first
+	mu      sync.Mutex
+	pending []ringSpan // Clips waiting for their post-event frames
+}
+
+// ringSpan is a ring file or clip, and the period it covers.
+type ringSpan struct {
+	name       string
+	start, end time.Time
+}
+
+// NewRingRecorder creates a RingRecorder that saves clips to dir.
+func NewRingRecorder(s *Snapper, dir string, pre, post time.Duration) *RingRecorder {
+	return &RingRecorder{Pre: pre, Post: post, Chunk: defaultRingChunk, Quality: jpeg.DefaultQuality, snapper: s, dir: dir}
+}
+
+// Trigger requests that a clip is saved to the file name.mjpeg.
+// The clip is written by Run once the frames after the trigger have been
+// recorded, or when Run returns. Trigger may be called from any goroutine.
+func (r *RingRecorder) Trigger(name string) {
+	now := time.Now()
+	r.mu.Lock()
+	r.pending = append(r.pending, ringSpan{name: filepath.Join(r.dir, name+".mjpeg"), start: now.Add(-r.Pre), end: now.Add(r.Post)})
+	r.mu.Unlock()
+}
+
+// Run records frames to the ring until the context is cancelled, when
+// any pending clips are saved with the frames recorded so far and
+// nil is returned.
+func (r *RingRecorder) Run(ctx context.Context) error {
+	ring := filepath.Join(r.dir, "ring")
+	if err := os.MkdirAll(ring, 0755); err != nil {
+		return err
+	}
+	chunk := r.Chunk
+	if chunk <= 0 {
+		chunk = defaultRingChunk
+	}
+	// The current ring file is written as a single segment recording.
+	seg := &Recorder{Segment: chunk, snapper: r.snapper, dir: ring}
+	passthrough := isJPEG(r.snapper.Format())
+	for {
+		select {
+		case <-ctx.Done():
+			return r.rotate(seg, time.Now(), true)
+		default:
+		}
+		f, err := r.snapper.Snap()
+		if err != nil {
+			r.rotate(seg, time.Now(), true)
+			return err
+		}
+		now := time.Now()
+		if seg.file != nil && seg.full(now) {
+			if err := r.rotate(seg, now, false); err != nil {
+				f.Release()
+				return err
+			}
+		}
+		if seg.file == nil {
+			if err := seg.create(now); err != nil {
+				f.Release()
+				return err
+			}
+		}
+		err = writeMJPEG(&countWriter{w: seg.w, n: &seg.written}, f, passthrough, r.Quality)
+		f.Release()
+		if err != nil {
+			r.rotate(seg, now, true)
+			return err
+		}
+	}
+}
+
+// rotate completes the current ring file, saves the clips whose post-event
+// period has ended (or all clips if final is set), and removes the ring
+// files that are no longer needed.
+func (r *RingRecorder) rotate(seg *Recorder, now time.Time, final bool) error {
+	if seg.file != nil {
+		name, start := seg.name, seg.start
+		if err := seg.finish(); err != nil {
+			return err
+		}
+		// The ring file ends when the next one starts, so there are no gaps.
+		r.chunks = append(r.chunks, ringSpan{name: name, start: start, end: now})
+	}
+	var ready, waiting []ringSpan
+	r.mu.Lock()
+	for _, clip := range r.pending {
+		if final || !clip.end.After(now) {
+			ready = append(ready, clip)
+		} else {
+			waiting = append(waiting, clip)
+		}
+	}
+	r.pending = waiting
+	r.mu.Unlock()
+	var err error
+	for _, clip := range ready {
+		if serr := r.save(clip); err == nil {
+			err = serr
+		}
+	}
+	// Keep the ring files covering Pre, and any needed by waiting clips.
+	keep := now.Add(-r.Pre)
+	for _, clip := range waiting {
+		if clip.start.Before(keep) {
+			keep = clip.start
+		}
+	}
+	for len(r.chunks) > 0 && r.chunks[0].end.Before(keep) {
+		os.Remove(r.chunks[0].name)
+		r.chunks = r.chunks[1:]
+	}
+	return err
+}
+
+// save writes the clip from the ring files that overlap it.
+func (r *RingRecorder) save(clip ringSpan) error {
+	file, err := os.Create(clip.name + partSuffix)
+	if err != nil {
+		return err
+	}
+	for _, c := range r.chunks {
+		if c.end.After(clip.start) && !c.start.After(clip.end) {
+			if err = appendFile(file, c.name); err != nil {
+				break
+			}
+		}
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(clip.name + partSuffix)
+		return fmt.Errorf("%s: %v", clip.name, err)
+	}
+	return os.Rename(clip.name+partSuffix, clip.name)
+}
+
+// appendFile copies the contents of the named file to w.
+func appendFile(w io.Writer, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
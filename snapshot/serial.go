@@ -0,0 +1,44 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sysfs root for video4linux devices.
+var sysfsVideo = "/sys/class/video4linux"
+
+// Maximum number of parent directories searched for a serial attribute.
+const serialSearchDepth = 4
+
+// SerialNumber returns the serial number of the open camera, as reported
+// by the device (e.g the USB iSerial descriptor). This identifies a
+// camera regardless of the /dev/videoN name it is assigned.
+// An error is returned if the device does not report a serial number.
+func (c *Snapper) SerialNumber() (string, error) {
+	if err := c.checkOpen(); err != nil {
+		return "", err
+	}
+	// Resolve links such as /dev/v4l/by-id to the underlying device.
+	dev, err := filepath.EvalSymlinks(c.device)
+	if err != nil {
+		return "", err
+	}
+	// The sysfs device link refers to the interface, and the serial
+	// attribute belongs to an ancestor (e.g the USB device).
+	dir, err := filepath.EvalSymlinks(filepath.Join(sysfsVideo, filepath.Base(dev), "device"))
+	if err != nil {
+		return "", fmt.Errorf("%s: no sysfs device: %v", c.device, err)
+	}
+	for i := 0; i < serialSearchDepth; i++ {
+		if b, err := os.ReadFile(filepath.Join(dir, "serial")); err == nil {
+			if s := strings.TrimSpace(string(b)); s != "" {
+				return s, nil
+			}
+		}
+		dir = filepath.Dir(dir)
+	}
+	return "", fmt.Errorf("%s: serial number not available", c.device)
+}
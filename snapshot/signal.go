@@ -0,0 +1,92 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// Encoder encodes frames written by CaptureUntilSignal.
+type Encoder interface {
+	Encode(w io.Writer, img image.Image) error
+	// Extension returns the file name extension, e.g ".jpg".
+	Extension() string
+}
+
+// JPEGEncoder encodes frames as JPEG at the quality given.
+type JPEGEncoder struct {
+	Quality int
+}
+
+func (e JPEGEncoder) Encode(w io.Writer, img image.Image) error {
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: e.Quality})
+}
+
+func (e JPEGEncoder) Extension() string {
+	return ".jpg"
+}
+
+// PNGEncoder encodes frames as PNG.
+type PNGEncoder struct{}
+
+func (e PNGEncoder) Encode(w io.Writer, img image.Image) error {
+	return png.Encode(w, img)
+}
+
+func (e PNGEncoder) Extension() string {
+	return ".png"
+}
+
+// CaptureUntilSignal writes each frame to a file in dir until one of
+// the signals is received, then closes the camera and returns nil.
+// If no signals are given, SIGINT and SIGTERM are used.
+// Files are named from the frame timestamp and sequence number, and are
+// written with a .part suffix that is removed once the file is complete,
+// so that a partial file is never mistaken for a frame.
+func (c *Snapper) CaptureUntilSignal(dir string, enc Encoder, sigs ...os.Signal) error {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), sigs...)
+	defer stop()
+	err := c.Each(ctx, func(f frame.Frame) error {
+		name := filepath.Join(dir, fmt.Sprintf("%s-%06d%s",
+			frame.TimestampOf(f).Format("20060102-150405.000"), frame.SequenceOf(f), enc.Extension()))
+		return writeFrameFile(name, f, enc)
+	})
+	c.Close()
+	if ctx.Err() != nil {
+		// Stopped by a signal.
+		return nil
+	}
+	return err
+}
+
+// writeFrameFile encodes the image to a temporary file, and renames it
+// to name once complete.
+func writeFrameFile(name string, img image.Image, enc Encoder) error {
+	tmp := name + ".part"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := enc.Encode(file, img); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, name)
+}
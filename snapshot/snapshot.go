@@ -3,6 +3,10 @@ package snapshot
 
 import (
 	"fmt"
+	"image"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/aamcrae/webcam"
 	"github.com/aamcrae/webcam/frame"
@@ -11,64 +15,370 @@ import (
 const (
 	defaultTimeout = 5
 	defaultBuffers = 16
+	// Number of additional frames tried when the Validator rejects a frame.
+	defaultValidateRetries = 4
+	// Time allowed beyond Timeout for the capture goroutine to exit on Close.
+	closeGrace = time.Second
 )
 
 type snap struct {
+	cam   camera // The camera the frame was captured from.
 	frm   []byte
 	index uint32
+	info  webcam.FrameInfo
 }
 
 type Snapper struct {
-	cam     *webcam.Webcam
+	// stats is first to ensure 64 bit alignment for atomic access.
+	stats counters
+
 	Timeout uint32
 	Buffers uint32
-	framer  func([]byte, func()) (frame.Frame, error)
-	stop    chan struct{}
-	stream  chan snap
+	Options []frame.Option // Options applied to each frame.
+	// FirstFrameTimeout and FrameTimeout, if non-zero, are the seconds
+	// allowed for the first frame after streaming starts and between the
+	// following frames. If a frame is not read in time, capture stops with
+	// ErrStalled. A generous FirstFrameTimeout allows for cameras that are
+	// slow to start, while a short FrameTimeout detects a stalled camera
+	// quickly. While a limit applies, each wait for a frame is at least
+	// a second, even if Timeout is zero. If zero, capture waits for
+	// frames indefinitely.
+	FirstFrameTimeout uint32
+	FrameTimeout      uint32
+	// SwapRB swaps the red and blue channels of RGB frames, to correct
+	// drivers that mislabel the channel order (see frame.SwapRB).
+	SwapRB bool
+	// MaxOutstanding, if non-zero, limits the number of frames that may
+	// be held without being released. Once reached, Snap and TrySnap return
+	// ErrTooManyOutstanding rather than waiting for buffers to run out.
+	MaxOutstanding int
+	// ReleaseHook, if set, is called when a frame returned by Snap is
+	// released, with the index of the frame's buffer and how long the
+	// frame was held, e.g to find consumers that hold buffers for long
+	// enough to cause frames to be dropped. It is called from the
+	// goroutine that releases the frame.
+	ReleaseHook func(index uint32, heldFor time.Duration)
+	// DecodeOnSnap causes Snap to decode frames that are otherwise
+	// decoded when first accessed (see frame.Decoder), so that a frame
+	// that cannot be decoded (e.g a corrupt MJPEG frame) is released and
+	// the error returned by Snap, rather than appearing as an empty image.
+	// Frames of formats that cannot be decoded (e.g H264) are rejected.
+	DecodeOnSnap bool
+	// LeakDetect enables tracking of frames that are not released.
+	// This is a debugging aid, and adds a small cost to each frame.
+	LeakDetect bool
+	// IOMethod selects how frame buffers are allocated (default MMAP).
+	IOMethod IOMethod
+	// AutoBuffers sets Buffers in Open to suit the frame size
+	// and frame rate (see SuggestBuffers). The chosen value can be
+	// read from Buffers after Open.
+	AutoBuffers bool
+	// RequireExactFormat causes Open to fail if the driver selects
+	// a different pixel format to the one requested. Otherwise frames
+	// are decoded in the format selected (see Format).
+	RequireExactFormat bool
+	// WarmupFrames is the number of frames discarded by Open after
+	// streaming starts (see Warmup).
+	WarmupFrames int
+	// Validator, if set, is called by Snap for each frame. If it returns
+	// an error the frame is released and the next frame is tried, up to
+	// ValidateRetries more times.
+	Validator       func(frame.Frame) error
+	ValidateRetries int
+	// IdleTimeout, if non-zero, pauses streaming and closes the device
+	// if no frames are read for the timeout and none are held. The
+	// configuration is kept, so the camera is reopened when next used.
+	IdleTimeout time.Duration
+	// ValidMinLuminance and ValidMinStdDev are the thresholds used by
+	// IsProducingValidFrames. If zero, defaults of 8 and 2 are used.
+	ValidMinLuminance float64
+	ValidMinStdDev    float64
+	// SettleFrames is the number of frames discarded by SnapWithControls
+	// while new control values take effect.
+	SettleFrames int
+	// RestoreSnapControls causes SnapWithControls to restore the
+	// previous control values after the frame is snapped.
+	RestoreSnapControls bool
+	// RecoverPanics causes a panic in the capture goroutine to be
+	// reported as an error (see Errors) and the stream to be closed,
+	// rather than terminating the process. It is set by NewSnapper.
+	RecoverPanics bool
+	// AutoOrient causes frames to be rotated to correct for the mounting
+	// rotation of the sensor, if the camera reports it. The rotation
+	// is read when the camera is opened.
+	AutoOrient bool
+	// Colorimetry is requested from the driver when the camera is opened.
+	// If the driver reports limited range or default quantization
+	// (which for YUV formats is limited range), YUV frames are decoded
+	// as limited range (see frame.LimitedRange). If it reports full
+	// range, the frames are decoded without expansion.
+	Colorimetry webcam.Colorimetry
+	// LowLightMode configures the camera for low light capture when it
+	// is opened (see LowLightSettings). LowLightExposure and LowLightGain
+	// are the absolute exposure and gain used, or the control maximum
+	// if zero. SnapAveraged may be used to further reduce noise.
+	LowLightMode     bool
+	LowLightExposure int32
+	LowLightGain     int32
+	// AutoReconnect causes the camera to be reopened with the same
+	// settings if the device disappears (e.g it is unplugged), or stalls
+	// (see FrameTimeout).
+	// Snap blocks while reconnecting. Controls should not be accessed
+	// until streaming resumes.
+	AutoReconnect bool
+	// ReconnectInterval is the delay between reconnection attempts.
+	// If zero, one second is used.
+	ReconnectInterval time.Duration
+
+	stateMu     sync.Mutex // Guards state, and serialises Open and Close.
+	stateCond   *sync.Cond // Signalled when the state changes from stateStopping.
+	state       state
+	readers     int           // Number of Snap calls reading the stream.
+	lastUse     time.Time     // When the stream or camera was last used.
+	idleStop    chan struct{} // Closed to stop the idle monitor.
+	camMu       sync.Mutex    // Guards cam, which is replaced by reconnect.
+	cam         *streamCam
+	framer      func([]byte, func()) (frame.Frame, error)
+	framerMu    sync.Mutex    // Guards framer and orient, which may be changed while streaming.
+	stop        chan struct{} // Closed to stop the capture goroutine.
+	done        chan struct{} // Closed when the capture goroutine exits.
+	stream      chan snap
+	prevMu      sync.Mutex  // Guards prev, so that SnapChanged may be called concurrently.
+	prev        *image.Gray // Previous frame used by SnapChanged.
+	outstanding int32
+	monotonic   int32             // If non-zero, report timestamps using the monotonic clock.
+	field       FieldMode         // Field mode requested in Open.
+	queued      int32             // If non-zero, capture does not drop frames.
+	dequeued    int32             // Buffers dequeued from the driver and not yet released.
+	buffers     int32             // Number of buffers allocated by the driver.
+	orient      frame.Orientation // Transform applied if AutoOrient is set.
+	limited     bool              // The driver reported limited range data.
+	rel         atomic.Value      // Holds the *releasers for the current camera.
+	lowLight    atomic.Value      // Holds the controls set by LowLightMode.
+	rateMu      sync.Mutex
+	rate        time.Duration // Minimum interval between frames returned by Snap.
+	lastSnap    time.Time     // When Snap last returned a frame under the consumer rate.
+	format      frame.FourCC
+	prefs       []frame.FourCC  // Format preferences for Open without a format, guarded by stateMu.
+	metering    image.Rectangle // Region used for exposure metering, guarded by stateMu.
+	callbackMu  sync.Mutex
+	callbacks   []func(frame.Frame) // Called by capture for each frame.
+	fps         float64             // Frame rate requested with SetFrameRate.
+	// Frame layout reported by the driver.
+	width, height, stride, size int
+	strideOverride              int          // If non-zero, used instead of stride.
+	framerFormat                frame.FourCC // Format decoded by the framer, changed by SetFramer.
+	errs                        chan error
+	errMu                       sync.Mutex
+	err                         error // Error that stopped capture.
+	// Parameters passed to Open, used when reconnecting.
+	device       string
+	openW, openH int
+}
+
+// IOMethod selects the streaming I/O method.
+type IOMethod int
+
+const (
+	// MMAP uses buffers allocated by the driver and mapped into memory.
+	MMAP IOMethod = iota
+	// USERPTR uses buffers allocated in application memory.
+	// Not all drivers support this method, in which case Open will fail.
+	USERPTR
+)
+
+func (m IOMethod) memory() uint32 {
+	if m == USERPTR {
+		return webcam.V4L2_MEMORY_USERPTR
+	}
+	return webcam.V4L2_MEMORY_MMAP
 }
 
 // NewSnapper creates a new Snapper.
 func NewSnapper() *Snapper {
-	return &Snapper{Timeout: defaultTimeout, Buffers: defaultBuffers}
+	return &Snapper{
+		Timeout:         defaultTimeout,
+		Buffers:         defaultBuffers,
+		SettleFrames:    defaultSettleFrames,
+		ValidateRetries: defaultValidateRetries,
+		RecoverPanics:   true,
+		errs:            make(chan error, errorQueue),
+	}
 }
 
 // Close releases all current frames and shuts down the webcam.
+// If capture does not stop within Timeout and a short grace period,
+// as when the driver ignores the timeout, streaming is stopped to end
+// the wait. Closing a Snapper that is not open has no effect.
 func (c *Snapper) Close() {
-	if c.cam != nil {
-		c.stop <- struct{}{}
-		// Flush any remaining frames.
-		for f := range c.stream {
-			c.cam.ReleaseFrame(f.index)
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	c.waitStopped()
+	if c.state != stateClosed {
+		if c.idleStop != nil {
+			close(c.idleStop)
+			c.idleStop = nil
+		}
+		c.stopCapture(stateClosed)
+	}
+}
+
+// halt signals the capture goroutine to stop.
+func (c *Snapper) halt() {
+	if c.getCam() != nil {
+		atomic.StoreInt32(&c.stats.streaming, 0)
+		close(c.stop)
+	}
+}
+
+// shutdown waits for the capture goroutine to stop once halted, and
+// closes the webcam.
+func (c *Snapper) shutdown() {
+	if c.getCam() == nil {
+		return
+	}
+	if !c.drain() {
+		// The capture goroutine is blocked in the driver. Stopping
+		// streaming unblocks it, and it must exit before the camera
+		// is closed, since it is still using the camera.
+		c.getCam().StopStreaming()
+		<-c.done
+		c.drain()
+	}
+	// The camera may have been replaced by reconnect.
+	c.getCam().shutdown()
+	c.setCam(nil)
+}
+
+// drain releases the frames in the stream until the capture goroutine
+// has exited. The capture goroutine notices the stop signal within
+// Timeout seconds, but the wait is bounded in case the driver blocks.
+// It returns false if the capture goroutine has not exited.
+func (c *Snapper) drain() bool {
+	limit := time.NewTimer(time.Duration(c.Timeout)*time.Second + closeGrace)
+	defer limit.Stop()
+	stream := c.stream
+	for stream != nil {
+		select {
+		case f, ok := <-stream:
+			if !ok {
+				stream = nil
+				break
+			}
+			c.release(f)
+		case <-limit.C:
+			return false
+		}
+	}
+	if c.done != nil {
+		select {
+		case <-c.done:
+		case <-limit.C:
+			return false
 		}
-		c.cam.StopStreaming()
-		c.cam.Close()
-		c.cam = nil
 	}
+	return true
 }
 
 // Open initialises the webcam ready for use, and begins streaming.
-func (c *Snapper) Open(device string, format frame.FourCC, w, h int) (ret error) {
-	pf, err := frame.FourCCToPixelFormat(format)
+// If format is empty, a format supported by the camera is chosen
+// (see Format). If the width and height are zero, the default resolution
+// for the format is used (see DefaultResolution), and if the format is
+// also empty the camera's current format is used.
+// ErrAlreadyOpen is returned if the camera is already open.
+func (c *Snapper) Open(device string, format frame.FourCC, w, h int) error {
+	return c.openPrefs(device, format, w, h, nil)
+}
+
+// openPrefs implements Open, using the format preferences to choose
+// the format if format is empty. The preferences are only used while
+// stateMu is held, so that concurrent calls do not see them.
+func (c *Snapper) openPrefs(device string, format frame.FourCC, w, h int, prefs []frame.FourCC) error {
+	c.stateMu.Lock()
+	c.waitStopped()
+	if c.state != stateClosed {
+		c.stateMu.Unlock()
+		return ErrAlreadyOpen
+	}
+	c.framerFormat = ""
+	c.prefs = prefs
+	err := c.open(device, format, w, h)
+	c.prefs = nil
+	if err == nil {
+		c.state = stateStreaming
+		c.lastUse = time.Now()
+		if c.IdleTimeout > 0 {
+			c.idleStop = make(chan struct{})
+			go c.watchIdle(c.idleStop)
+		}
+	}
+	c.stateMu.Unlock()
 	if err != nil {
 		return err
 	}
-	if c.cam != nil {
+	if err := c.Warmup(c.WarmupFrames); err != nil {
 		c.Close()
-	}
-	cam, err := webcam.Open(device)
-	if err != nil {
 		return err
 	}
+	return nil
+}
+
+// open starts the camera and the capture goroutine.
+func (c *Snapper) open(device string, format frame.FourCC, w, h int) (ret error) {
+	if c.errs == nil {
+		c.errs = make(chan error, errorQueue)
+	}
+	c.setErr(nil)
+	c.stop = make(chan struct{})
+	c.done = nil
+	// The stream holds the latest frame, so that it is available
+	// immediately to TrySnap.
+	c.stream = make(chan snap, 1)
 	// Add a defer function that closes the camera in the event of an error.
 	defer func() {
 		if ret != nil {
 			close(c.stream)
-			c.Close()
+			c.halt()
+			c.shutdown()
 		}
 	}()
-	c.cam = cam
-	c.stop = make(chan struct{}, 1)
-	c.stream = make(chan snap, 0)
+	if err := c.start(device, format, w, h); err != nil {
+		return err
+	}
+	c.device, c.openW, c.openH = device, w, h
+	atomic.StoreInt64(&c.stats.started, time.Now().UnixNano())
+	atomic.StoreInt64(&c.stats.lastFrame, 0)
+	atomic.StoreInt32(&c.stats.streaming, 1)
+	c.done = make(chan struct{})
+	go c.run()
+	return nil
+}
+
+// start opens and configures the device, and begins streaming.
+// c.cam is only replaced once the device has been opened.
+// start is called with stateMu held. c.cam is not replaced by other
+// goroutines while starting, so it is read without camMu.
+func (c *Snapper) start(device string, format frame.FourCC, w, h int) error {
+	cam, err := openCamera(device)
+	if err != nil {
+		return err
+	}
+	c.setCam(&streamCam{camera: cam})
+	if w == 0 && h == 0 {
+		if format, w, h, err = c.defaultFormat(format); err != nil {
+			return fmt.Errorf("%s: %v", device, err)
+		}
+	}
+	if format == "" {
+		if format, err = c.chooseFormat(w, h); err != nil {
+			return fmt.Errorf("%s: %v", device, err)
+		}
+	}
+	pf, err := frame.FourCCToPixelFormat(format)
+	if err != nil {
+		return err
+	}
+	c.format = format
 	// Get the supported formats and their descriptions.
 	_, ok := c.cam.GetSupportedFormats()[pf]
 	if !ok {
@@ -84,79 +394,476 @@ func (c *Snapper) Open(device string, format frame.FourCC, w, h int) (ret error)
 	if !found {
 		return fmt.Errorf("%s: unsupported resolution: %dx%d", device, w, h)
 	}
+	c.cam.SetField(uint32(c.field))
+	c.cam.SetColorimetry(c.Colorimetry)
 	npf, nw, nh, stride, size, err := c.cam.SetImageFormat(pf, uint32(w), uint32(h))
 
 	if err != nil {
 		return err
 	}
+	if npf != pf && c.RequireExactFormat {
+		return fmt.Errorf("%s: asked for format %s, got %s", device, format, frame.PixelFormatToFourCC(npf))
+	}
 	if npf != pf || w != int(nw) || h != int(nh) {
 		fmt.Printf("Asked for %08x %dx%d, got %08x %dx%d\n", pf, w, h, npf, nw, nh)
 	}
-	if c.framer, err = frame.GetFramer(format, w, h, int(stride), int(size)); err != nil {
+	if npf != pf {
+		// Decode frames in the format selected by the driver, which is
+		// also requested if the camera is reopened.
+		format = frame.PixelFormatToFourCC(npf)
+		c.format = format
+	}
+	c.width, c.height, c.stride, c.size = w, h, int(stride), int(size)
+	// The default quantization of YUV formats is limited range.
+	switch c.cam.GetColorimetry().Quantization {
+	case webcam.V4L2_QUANTIZATION_LIM_RANGE, webcam.V4L2_QUANTIZATION_DEFAULT:
+		c.limited = true
+	default:
+		c.limited = false
+	}
+	// A framer chosen with SetFramer is kept when the camera is reopened.
+	ff := c.framerFormat
+	if ff == "" {
+		ff = format
+	}
+	if err := c.makeFramer(ff); err != nil {
 		return err
 	}
+	orient := frame.OrientNormal
+	if c.AutoOrient {
+		orient = c.sensorOrientation()
+	}
+	c.framerMu.Lock()
+	c.orient = orient
+	c.framerMu.Unlock()
 
+	if c.fps > 0 {
+		if err := c.cam.SetFramerate(c.fps); err != nil {
+			return fmt.Errorf("%s: set frame rate: %v", device, err)
+		}
+	}
+	if c.AutoBuffers {
+		c.autoBuffers()
+	}
 	c.cam.SetBufferCount(c.Buffers)
+	if err := c.cam.SetIOMethod(c.IOMethod.memory()); err != nil {
+		return err
+	}
 	c.cam.SetAutoWhiteBalance(true)
+	if c.LowLightMode {
+		if err := c.applyLowLight(); err != nil {
+			return fmt.Errorf("%s: low light: %v", device, err)
+		}
+	}
 	if err := c.cam.StartStreaming(); err != nil {
 		return err
 	}
-	go c.capture()
+	atomic.StoreInt32(&c.dequeued, 0)
+	atomic.StoreInt32(&c.buffers, int32(c.cam.GetBufferCount()))
+	c.makeReleasers(c.cam, int(c.cam.GetBufferCount()))
+	return nil
+}
+
+// makeFramer creates the framer for the format and current frame layout.
+func (c *Snapper) makeFramer(format frame.FourCC) error {
+	stride := c.stride
+	if c.strideOverride != 0 {
+		stride = c.strideOverride
+	}
+	opts := c.Options
+	if c.SwapRB {
+		opts = append(opts[:len(opts):len(opts)], frame.SwapRB())
+	}
+	if c.limited {
+		opts = append(opts[:len(opts):len(opts)], frame.LimitedRange())
+	}
+	framer, err := frame.GetFramer(format, c.width, c.height, stride, c.size, opts...)
+	if err != nil {
+		return err
+	}
+	c.framerMu.Lock()
+	c.framer = framer
+	c.framerMu.Unlock()
+	c.framerFormat = format
 	return nil
 }
 
+// getCam returns the current camera, or nil if the camera is closed.
+func (c *Snapper) getCam() *streamCam {
+	c.camMu.Lock()
+	defer c.camMu.Unlock()
+	return c.cam
+}
+
+func (c *Snapper) setCam(cam *streamCam) {
+	c.camMu.Lock()
+	c.cam = cam
+	c.camMu.Unlock()
+}
+
+// getFramer returns the current framer, and the orientation applied to frames.
+func (c *Snapper) getFramer() (func([]byte, func()) (frame.Frame, error), frame.Orientation) {
+	c.framerMu.Lock()
+	defer c.framerMu.Unlock()
+	return c.framer, c.orient
+}
+
 // Snap returns one frame from the camera.
+// The capture goroutine keeps the latest frame waiting in a buffered
+// stream (so that TrySnap can return it immediately), so the frame
+// returned may have been captured before Snap was called. The waiting
+// frame holds one of the driver's buffers until it is read or replaced.
+// FlushAndSnap may be used if the frame must be captured after the call.
+// If a consumer rate is set (see SetConsumerRate), Snap waits until
+// the next frame is due. If a Validator is set, rejected frames are
+// released and replaced by the following frames.
 func (c *Snapper) Snap() (frame.Frame, error) {
-	snap, ok := <-c.stream
+	return c.snapOpts()
+}
+
+// SnapTagged returns one frame from the camera as Snap does, with the
+// tag attached to the frame (see frame.WithTag), so that the frame can
+// be matched to the work it was requested for using frame.TagOf.
+func (c *Snapper) SnapTagged(tag interface{}) (frame.Frame, error) {
+	return c.snapOpts(frame.WithTag(tag))
+}
+
+// snapOpts implements Snap, applying the options to the frame.
+func (c *Snapper) snapOpts(opts ...frame.Option) (frame.Frame, error) {
+	c.throttle()
+	if c.Validator == nil {
+		return c.snap(opts...)
+	}
+	var verr error
+	for i := 0; i <= c.ValidateRetries; i++ {
+		f, err := c.snap(opts...)
+		if err != nil {
+			return nil, err
+		}
+		if verr = c.Validator(f); verr == nil {
+			return f, nil
+		}
+		f.Release()
+	}
+	return nil, fmt.Errorf("frame rejected: %w", verr)
+}
+
+// snap returns the next frame from the stream, ignoring any consumer rate.
+// Any options are applied to the frame.
+func (c *Snapper) snap(opts ...frame.Option) (frame.Frame, error) {
+	if !c.reserve() {
+		return nil, ErrTooManyOutstanding
+	}
+	stream, err := c.getStream()
+	if err != nil {
+		c.unreserve()
+		return nil, err
+	}
+	defer c.doneStream()
+	snap, ok := <-stream
 	if !ok {
-		return nil, fmt.Errorf("No frame received")
+		c.unreserve()
+		return nil, c.streamErr()
+	}
+	return c.newFrame(snap, opts...)
+}
+
+// reserve counts a frame as outstanding before it is read, so that
+// concurrent calls cannot together exceed MaxOutstanding. It returns
+// false if MaxOutstanding frames are already outstanding.
+func (c *Snapper) reserve() bool {
+	for {
+		n := atomic.LoadInt32(&c.outstanding)
+		if c.MaxOutstanding > 0 && int(n) >= c.MaxOutstanding {
+			return false
+		}
+		if atomic.CompareAndSwapInt32(&c.outstanding, n, n+1) {
+			return true
+		}
+	}
+}
+
+// unreserve undoes reserve when no frame is returned.
+func (c *Snapper) unreserve() {
+	atomic.AddInt32(&c.outstanding, -1)
+}
+
+// TrySnap returns a frame from the camera if one is available,
+// without blocking. As with Snap, the frame is the latest frame waiting
+// in the stream, which may have been captured some time before the call. If no frame is ready, or the next frame is not yet
+// due under the consumer rate, (nil, false, nil) is returned.
+func (c *Snapper) TrySnap() (frame.Frame, bool, error) {
+	stream, err := c.getStream()
+	if err != nil {
+		return nil, false, err
+	}
+	defer c.doneStream()
+	if !c.due() {
+		return nil, false, nil
+	}
+	if !c.reserve() {
+		return nil, false, ErrTooManyOutstanding
+	}
+	select {
+	case snap, ok := <-stream:
+		if !ok {
+			c.unreserve()
+			return nil, false, c.streamErr()
+		}
+		c.delivered()
+		f, err := c.newFrame(snap)
+		if err == nil && c.Validator != nil {
+			if err = c.Validator(f); err != nil {
+				// A rejected frame is treated as not available.
+				f.Release()
+				return nil, false, nil
+			}
+		}
+		return f, err == nil, err
+	default:
+		c.unreserve()
+		return nil, false, nil
+	}
+}
+
+// release returns the frame's buffer to the camera it was captured from.
+func (c *Snapper) release(s snap) {
+	atomic.AddInt32(&c.dequeued, -1)
+	s.cam.ReleaseFrame(s.index)
+}
+
+// releasers holds a release function for each buffer of a camera,
+// so that a closure is not allocated for every frame. A buffer holds
+// one frame at a time, so the time it was snapped is kept with it.
+type releasers struct {
+	cam   camera
+	fns   []func()
+	taken []time.Time
+}
+
+// makeReleasers creates the release functions for the buffers of the camera.
+func (c *Snapper) makeReleasers(cam camera, n int) {
+	r := &releasers{cam: cam, fns: make([]func(), n), taken: make([]time.Time, n)}
+	for i := range r.fns {
+		r.fns[i] = c.newReleaser(snap{cam: cam, index: uint32(i)}, &r.taken[i])
+	}
+	c.rel.Store(r)
+}
+
+// releaser returns the function that releases the frame's buffer,
+// recording the time the frame was snapped if ReleaseHook is set.
+func (c *Snapper) releaser(s snap) func() {
+	if r, ok := c.rel.Load().(*releasers); ok && r.cam == s.cam && int(s.index) < len(r.fns) {
+		if c.ReleaseHook != nil {
+			r.taken[s.index] = time.Now()
+		}
+		return r.fns[s.index]
+	}
+	taken := new(time.Time)
+	if c.ReleaseHook != nil {
+		*taken = time.Now()
+	}
+	return c.newReleaser(s, taken)
+}
+
+// newReleaser returns a function that releases the buffer, and calls
+// ReleaseHook with the time since the frame was snapped at taken.
+func (c *Snapper) newReleaser(s snap, taken *time.Time) func() {
+	return func() {
+		hook := c.ReleaseHook
+		var held time.Duration
+		if hook != nil {
+			// Read before the buffer is requeued and snapped again.
+			held = time.Since(*taken)
+		}
+		atomic.AddInt32(&c.outstanding, -1)
+		c.release(s)
+		if hook != nil {
+			hook(s.index, held)
+		}
+	}
+}
+
+// discardStale releases the frame waiting in the stream, if any,
+// so that the next frame read is captured after this call.
+func (c *Snapper) discardStale() {
+	select {
+	case s, ok := <-c.stream:
+		if ok {
+			c.release(s)
+		}
+	default:
+	}
+}
+
+// newFrame wraps the raw frame using the framer, and applies the options.
+// The frame must have been reserved, and is no longer outstanding once released.
+func (c *Snapper) newFrame(snap snap, opts ...frame.Option) (frame.Frame, error) {
+	f, err := c.wrapFrame(snap, c.releaser(snap))
+	if err != nil {
+		return nil, err
+	}
+	if c.DecodeOnSnap {
+		if err := frame.DecodeErr(f); err != nil {
+			f.Release()
+			return nil, err
+		}
+	}
+	for _, o := range opts {
+		o(f)
+	}
+	if c.LeakDetect {
+		f = c.trackLeak(f)
+	}
+	return f, nil
+}
+
+// wrapFrame creates a frame with the frame metadata, that calls rel when released.
+func (c *Snapper) wrapFrame(snap snap, rel func()) (frame.Frame, error) {
+	framer, orient := c.getFramer()
+	f, err := framer(snap.frm, rel)
+	if err != nil {
+		return nil, err
+	}
+	frame.WithTimestamp(c.timestamp(snap.info))(f)
+	frame.WithSequence(snap.info.Sequence)(f)
+	frame.WithFramer(framer)(f)
+	return frame.Orient(f, orient), nil
+}
+
+// stopped returns true once capture has been signalled to stop.
+func (c *Snapper) stopped() bool {
+	select {
+	case <-c.stop:
+		return true
+	default:
+		return false
 	}
-	return c.framer(snap.frm, func() {
-		c.cam.ReleaseFrame(snap.index)
-	})
 }
 
 // capture continually reads frames and either discards the frames or
-// sends them to a channel that is ready.
-func (c *Snapper) capture() {
+// sends them to a channel that is ready. It returns nil when stopped,
+// or the error that prevented further frames from being read.
+// Errors once stopped are ignored, since they may be caused by
+// shutdown stopping the camera while a frame is awaited.
+func (c *Snapper) capture() error {
+	cam := c.cam
+	// The time allowed for the next frame, and the time waited so far.
+	limit, waited := c.FirstFrameTimeout, uint32(0)
+	// Consecutive transient errors.
+	retries := 0
 	for {
-		err := c.cam.WaitForFrame(c.Timeout)
+		timeout := c.Timeout
+		if limit != 0 {
+			if limit-waited < timeout {
+				timeout = limit - waited
+			}
+			// Wait at least a second, so that the limit is reached
+			// if Timeout is zero.
+			if timeout == 0 {
+				timeout = 1
+			}
+		}
+		err := cam.WaitForFrame(timeout)
 
 		switch err.(type) {
 		case nil:
 		case *webcam.Timeout:
+			if c.stopped() {
+				return nil
+			}
+			waited += timeout
+			if limit != 0 && waited >= limit {
+				return fmt.Errorf("%w: no frame for %d seconds", ErrStalled, waited)
+			}
 			continue
 		default:
-			panic(err)
+			if c.stopped() {
+				return nil
+			}
+			if transient(err) && retries < maxTransientErrors {
+				retries++
+				if !c.backoff() {
+					return nil
+				}
+				continue
+			}
+			return captureError(err)
 		}
+		limit, waited = c.FrameTimeout, 0
 
-		frame, index, err := c.cam.GetFrame()
+		frame, index, info, err := cam.GetFrameInfo()
 		if err != nil {
-			panic(err)
+			if c.stopped() {
+				return nil
+			}
+			if transient(err) && retries < maxTransientErrors {
+				retries++
+				if !c.backoff() {
+					return nil
+				}
+				continue
+			}
+			return captureError(err)
+		}
+		retries = 0
+		atomic.AddInt32(&c.dequeued, 1)
+		c.stats.frameCaptured(time.Now())
+		s := snap{cam, frame, index, info}
+		c.notify(s)
+		if atomic.LoadInt32(&c.queued) != 0 {
+			// In queued mode, wait for the frame to be read
+			// rather than dropping it.
+			select {
+			case c.stream <- s:
+			case <-c.stop:
+				c.release(s)
+				return nil
+			}
+			continue
 		}
 		select {
 		// Only executed if stream is ready to receive.
-		case c.stream <- snap{frame, index}:
+		case c.stream <- s:
 		// Signal to stop streaming.
 		case <-c.stop:
 			// Finish up.
-			c.cam.ReleaseFrame(index)
-			close(c.stream)
-			return
+			c.release(s)
+			return nil
 		default:
-			c.cam.ReleaseFrame(index)
+			// Replace the stale frame waiting in the stream.
+			select {
+			case old := <-c.stream:
+				c.release(old)
+				c.stats.frameDropped()
+			default:
+			}
+			select {
+			case c.stream <- s:
+			default:
+				c.release(s)
+				c.stats.frameDropped()
+			}
 		}
 	}
 }
 
 // GetControl returns the current value of a camera control.
 func (c *Snapper) GetControl(id webcam.ControlID) (int32, error) {
-	return c.cam.GetControl(id)
+	cam, err := c.openCam()
+	if err != nil {
+		return 0, err
+	}
+	return cam.GetControl(id)
 }
 
 // SetControl sets the selected camera control.
 func (c *Snapper) SetControl(id webcam.ControlID, value int32) error {
-	return c.cam.SetControl(id, value)
+	cam, err := c.openCam()
+	if err != nil {
+		return err
+	}
+	return cam.SetControl(id, value)
 }
 
 // Return true if frame size can accomodate request.
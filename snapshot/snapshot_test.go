@@ -0,0 +1,248 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aamcrae/webcam"
+	"github.com/aamcrae/webcam/frame"
+)
+
+// stalled waits for capture to stop with ErrStalled, and returns the
+// timeouts passed to WaitForFrame.
+func stalled(t *testing.T, c *Snapper, d *fakeDevice) []uint32 {
+	t.Helper()
+	select {
+	case err := <-c.Errors():
+		if !errors.Is(err, ErrStalled) {
+			t.Fatalf("Errors: got %v, want ErrStalled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("capture did not stall")
+	}
+	return d.last().timeouts()
+}
+
+func TestFrameTimeouts(t *testing.T) {
+	c := NewSnapper()
+	c.Timeout = 2
+	c.FirstFrameTimeout = 3
+	c.FrameTimeout = 1
+	d := openFake(t, c, func(cam *fakeCamera) {
+		// The first frame arrives after 3 seconds, the second promptly,
+		// and the third is not received within FrameTimeout.
+		cam.run(timeoutStep, fakeStep{}, fakeStep{}, timeoutStep)
+	})
+	got := stalled(t, c, d)
+	if want := []uint32{2, 1, 1, 1}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("timeouts: got %v, want %v", got, want)
+	}
+}
+
+func TestFrameTimeoutZeroTimeout(t *testing.T) {
+	c := NewSnapper()
+	c.Timeout = 0
+	c.FrameTimeout = 2
+	d := openFake(t, c, func(cam *fakeCamera) {
+		// A frame is ready, so that it is received without waiting.
+		cam.interval = 0
+		cam.run(fakeStep{}, timeoutStep, timeoutStep)
+	})
+	got := stalled(t, c, d)
+	// Without a limit for the first frame, Timeout is used as is.
+	if want := []uint32{0, 1, 1}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("timeouts: got %v, want %v", got, want)
+	}
+}
+
+func TestReleaseHook(t *testing.T) {
+	c := NewSnapper()
+	type release struct {
+		index   uint32
+		heldFor time.Duration
+	}
+	released := make(chan release, 4)
+	c.ReleaseHook = func(index uint32, heldFor time.Duration) {
+		released <- release{index, heldFor}
+	}
+	openFake(t, c, nil)
+	const hold = 20 * time.Millisecond
+	start := time.Now()
+	f, err := c.Snap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(hold)
+	f.Release()
+	max := time.Since(start)
+	select {
+	case r := <-released:
+		if r.index >= c.Buffers {
+			t.Errorf("index %d, with %d buffers", r.index, c.Buffers)
+		}
+		if r.heldFor < hold || r.heldFor > max {
+			t.Errorf("held for %v, want %v to %v", r.heldFor, hold, max)
+		}
+	default:
+		t.Fatal("ReleaseHook not called")
+	}
+	// Each frame is reported once, with the time it was held.
+	g, err := c.Snap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Release()
+	if r := <-released; r.heldFor >= hold {
+		t.Errorf("frame released at once held for %v", r.heldFor)
+	}
+	if len(released) != 0 {
+		t.Fatal("ReleaseHook called more than once for a frame")
+	}
+}
+
+// benchmarkSnap reads 640x480 frames of the format from a fake
+// camera that produces them without delay. Capture is queued, as by
+// Burst, so that each frame captured is read. If hook is set,
+// a ReleaseHook is also called for each frame.
+func benchmarkSnap(b *testing.B, format frame.FourCC, hook bool) {
+	useFake(b, func() *fakeCamera {
+		cam := newFakeCamera(format, 640, 480)
+		cam.interval = 0
+		return cam
+	})
+	c := NewSnapper()
+	c.Buffers = 4
+	c.queued = 1
+	if hook {
+		c.ReleaseHook = func(uint32, time.Duration) {}
+	}
+	if err := c.Open("/dev/fake", format, 640, 480); err != nil {
+		b.Fatal(err)
+	}
+	defer c.Close()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f, err := c.Snap()
+		if err != nil {
+			b.Fatal(err)
+		}
+		f.Release()
+	}
+}
+
+func BenchmarkSnapRGB3(b *testing.B)            { benchmarkSnap(b, "RGB3", false) }
+func BenchmarkSnapYUYV(b *testing.B)            { benchmarkSnap(b, "YUYV", false) }
+func BenchmarkSnapMJPEG(b *testing.B)           { benchmarkSnap(b, "MJPG", false) }
+func BenchmarkSnapYUYVReleaseHook(b *testing.B) { benchmarkSnap(b, "YUYV", true) }
+
+func TestQuantization(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		q        uint32
+		expanded bool
+	}{
+		{"default", webcam.V4L2_QUANTIZATION_DEFAULT, true},
+		{"limited", webcam.V4L2_QUANTIZATION_LIM_RANGE, true},
+		{"full", webcam.V4L2_QUANTIZATION_FULL_RANGE, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewSnapper()
+			openFake(t, c, func(cam *fakeCamera) {
+				cam.quantization = tc.q
+			})
+			f, err := c.Snap()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Release()
+			// The fake fills frames with the (small) sequence number,
+			// which is below the limited range black level.
+			raw := frame.RawOf(f)[0]
+			l := frame.LuminanceAt(f, 0, 0)
+			if tc.expanded && l != 0 {
+				t.Fatalf("luma %d decoded as %d, want 0", raw, l)
+			}
+			if !tc.expanded && l != raw {
+				t.Fatalf("full range luma %d decoded as %d", raw, l)
+			}
+		})
+	}
+}
+
+func TestSetTimestampSourceWhileStreaming(t *testing.T) {
+	c := NewSnapper()
+	openFake(t, c, nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			c.SetTimestampSource(i%2 == 0)
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		f, err := c.Snap()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if frame.TimestampOf(f).IsZero() {
+			t.Fatal("frame has no timestamp")
+		}
+		f.Release()
+	}
+	<-done
+	c.SetTimestampSource(false)
+	if off, err := c.ClockOffset(); err != nil || off != 0 {
+		t.Fatalf("ClockOffset with wall clock timestamps: got %v, %v", off, err)
+	}
+}
+
+func TestSnapChanged(t *testing.T) {
+	c := NewSnapper()
+	openFake(t, c, nil)
+	f, changed, err := c.SnapChanged(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Release()
+	if !changed {
+		t.Fatal("first frame not changed")
+	}
+	// Successive fake frames differ in luma by the difference in sequence.
+	f, changed, err = c.SnapChanged(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Release()
+	if changed {
+		t.Fatal("frame changed with a threshold above any difference")
+	}
+	f, changed, err = c.SnapChanged(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Release()
+	if !changed {
+		t.Fatal("different frame not changed")
+	}
+	// Concurrent callers share the previous frame.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				f, _, err := c.SnapChanged(0)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				f.Release()
+			}
+		}()
+	}
+	wg.Wait()
+}
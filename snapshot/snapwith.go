@@ -0,0 +1,56 @@
+package snapshot
+
+import (
+	"github.com/aamcrae/webcam"
+	"github.com/aamcrae/webcam/frame"
+)
+
+// Default number of frames discarded after changing controls in SnapWithControls.
+const defaultSettleFrames = 3
+
+// SnapWithControls sets the controls, snaps a frame taken with the new
+// values, and if RestoreSnapControls is set, restores the previous values.
+// Control changes are not instantaneous: frames already captured, and
+// often one or two frames after the change, reflect the old values.
+// So the frame waiting in the stream and SettleFrames further frames
+// are discarded before the frame is returned.
+func (c *Snapper) SnapWithControls(controls map[webcam.ControlID]int32) (frame.Frame, error) {
+	cam, err := c.openCam()
+	if err != nil {
+		return nil, err
+	}
+	var prev map[webcam.ControlID]int32
+	if c.RestoreSnapControls {
+		ids := make([]webcam.ControlID, 0, len(controls))
+		for id := range controls {
+			ids = append(ids, id)
+		}
+		var err error
+		if prev, err = cam.GetExtControls(ids); err != nil {
+			return nil, err
+		}
+	}
+	if err := cam.SetExtControls(controls); err != nil {
+		return nil, err
+	}
+	f, err := c.settleAndSnap()
+	if prev != nil {
+		// Restore the previous values even if the snap failed.
+		if rerr := cam.SetExtControls(prev); rerr != nil && err == nil {
+			f.Release()
+			return nil, rerr
+		}
+	}
+	return f, err
+}
+
+// settleAndSnap discards frames that may predate a control change,
+// then snaps a frame.
+func (c *Snapper) settleAndSnap() (frame.Frame, error) {
+	// Discard any stale frame waiting in the stream.
+	c.discardStale()
+	if err := c.Warmup(c.SettleFrames); err != nil {
+		return nil, err
+	}
+	return c.snap()
+}
@@ -0,0 +1,59 @@
+package snapshot
+
+import (
+	"github.com/aamcrae/webcam"
+)
+
+// StandardID is a set of analog video standards, such as PAL or NTSC.
+type StandardID uint64
+
+const (
+	StandardPAL   = StandardID(webcam.V4L2_STD_PAL)
+	StandardNTSC  = StandardID(webcam.V4L2_STD_NTSC)
+	StandardSECAM = StandardID(webcam.V4L2_STD_SECAM)
+)
+
+// StandardInfo describes a video standard supported by the current input.
+type StandardInfo struct {
+	ID      StandardID
+	Name    string
+	FPS     float64 // Nominal frame rate.
+	Lines   int     // Lines per frame, including blanking.
+	Current bool    // The standard includes the currently selected standard.
+}
+
+// Standards returns the video standards supported by the current input.
+// Only analog inputs have standards; other devices return an error.
+func (c *Snapper) Standards() ([]StandardInfo, error) {
+	cam, err := c.openCam()
+	if err != nil {
+		return nil, err
+	}
+	stds, err := cam.GetStandards()
+	if err != nil {
+		return nil, err
+	}
+	// Some drivers cannot report the current standard, which is not an error.
+	cur, _ := cam.GetStandard()
+	var info []StandardInfo
+	for _, s := range stds {
+		si := StandardInfo{ID: StandardID(s.ID), Name: s.Name, Lines: int(s.Lines), Current: s.ID&cur != 0}
+		if s.FrameNum != 0 {
+			si.FPS = float64(s.FrameDenom) / float64(s.FrameNum)
+		}
+		info = append(info, si)
+	}
+	return info, nil
+}
+
+// SetStandard selects the video standard of an analog input. Without
+// the correct standard, analog devices deliver rolling or garbled frames.
+// The standard should be set before the input is streaming, so
+// the camera may need to be reopened to use the new standard.
+func (c *Snapper) SetStandard(id StandardID) error {
+	cam, err := c.openCam()
+	if err != nil {
+		return err
+	}
+	return cam.SetStandard(uint64(id))
+}
@@ -0,0 +1,118 @@
+package snapshot
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotOpen is returned when the camera must be open, but is not.
+	ErrNotOpen = errors.New("camera not open")
+	// ErrAlreadyOpen is returned when the camera must be closed, but is open.
+	ErrAlreadyOpen = errors.New("camera already open")
+)
+
+// state is the lifecycle state of a Snapper.
+type state int
+
+const (
+	stateClosed state = iota
+	stateStreaming
+	statePaused   // Open, but not streaming.
+	stateStopping // Streaming is being stopped (see stopCapture).
+)
+
+// waitStopped waits while streaming is being stopped.
+// It is called with stateMu held.
+func (c *Snapper) waitStopped() {
+	for c.state == stateStopping {
+		if c.stateCond == nil {
+			c.stateCond = sync.NewCond(&c.stateMu)
+		}
+		c.stateCond.Wait()
+	}
+}
+
+// stopCapture stops streaming and closes the camera, then sets the state.
+// It is called with stateMu held, which is released while waiting for the
+// capture goroutine to exit, so that the capture goroutine is not blocked
+// if it needs stateMu (e.g to reconnect) and other callers are not blocked
+// for up to Timeout seconds. Meanwhile the state is stateStopping.
+func (c *Snapper) stopCapture(next state) {
+	if c.state != stateStreaming {
+		c.state = next
+		return
+	}
+	c.state = stateStopping
+	c.halt()
+	c.stateMu.Unlock()
+	c.shutdown()
+	c.stateMu.Lock()
+	c.state = next
+	if c.stateCond != nil {
+		c.stateCond.Broadcast()
+	}
+}
+
+// getState returns the current state.
+func (c *Snapper) getState() state {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.state
+}
+
+// checkOpen returns ErrNotOpen if the camera is closed. If streaming
+// has been paused because the Snapper is idle, it is resumed.
+func (c *Snapper) checkOpen() error {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.ready()
+}
+
+// openCam returns the camera as checkOpen does, so that the camera can be
+// used while it may be replaced by reconnect.
+func (c *Snapper) openCam() (*streamCam, error) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if err := c.ready(); err != nil {
+		return nil, err
+	}
+	return c.getCam(), nil
+}
+
+// ready resumes streaming if paused, and records the use of the camera.
+// It is called with stateMu held.
+func (c *Snapper) ready() error {
+	c.waitStopped()
+	switch c.state {
+	case stateClosed:
+		return ErrNotOpen
+	case statePaused:
+		if err := c.resume(); err != nil {
+			return err
+		}
+	}
+	c.lastUse = time.Now()
+	return nil
+}
+
+// getStream returns the frame stream, or ErrNotOpen if the camera is closed.
+// The caller must call doneStream once it has finished reading the stream.
+func (c *Snapper) getStream() (chan snap, error) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if err := c.ready(); err != nil {
+		return nil, err
+	}
+	c.readers++
+	return c.stream, nil
+}
+
+// doneStream records that a reader of the stream has finished.
+func (c *Snapper) doneStream() {
+	c.stateMu.Lock()
+	c.readers--
+	c.lastUse = time.Now()
+	c.stateMu.Unlock()
+}
@@ -0,0 +1,208 @@
+package snapshot
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNotOpen(t *testing.T) {
+	c := NewSnapper()
+	if _, err := c.Snap(); !errors.Is(err, ErrNotOpen) {
+		t.Fatalf("Snap: got %v, want ErrNotOpen", err)
+	}
+	if _, _, err := c.TrySnap(); !errors.Is(err, ErrNotOpen) {
+		t.Fatalf("TrySnap: got %v, want ErrNotOpen", err)
+	}
+	if _, err := c.GetControl(1); !errors.Is(err, ErrNotOpen) {
+		t.Fatalf("GetControl: got %v, want ErrNotOpen", err)
+	}
+	if err := c.SetControl(1, 0); !errors.Is(err, ErrNotOpen) {
+		t.Fatalf("SetControl: got %v, want ErrNotOpen", err)
+	}
+	// Closing a Snapper that is not open has no effect.
+	c.Close()
+	c.Close()
+}
+
+func TestAlreadyOpen(t *testing.T) {
+	c := NewSnapper()
+	d := openFake(t, c, nil)
+	if err := c.Open("/dev/fake", "YUYV", 4, 2); !errors.Is(err, ErrAlreadyOpen) {
+		t.Fatalf("Open: got %v, want ErrAlreadyOpen", err)
+	}
+	if len(d.opened()) != 1 {
+		t.Fatal("device opened twice")
+	}
+}
+
+func TestReopen(t *testing.T) {
+	c := NewSnapper()
+	d := openFake(t, c, nil)
+	for i := 0; i < 3; i++ {
+		c.Close()
+		if err := c.Open("/dev/fake", "YUYV", 4, 2); err != nil {
+			t.Fatal(err)
+		}
+		f, err := c.Snap()
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Release()
+	}
+	cams := d.opened()
+	for _, cam := range cams[:len(cams)-1] {
+		if !cam.isClosed() {
+			t.Fatal("camera not closed")
+		}
+	}
+}
+
+func TestConcurrentOpenClose(t *testing.T) {
+	c := NewSnapper()
+	d := openFake(t, c, nil)
+	c.Close()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	opened := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			err := c.Open("/dev/fake", "YUYV", 4, 2)
+			if err == nil {
+				mu.Lock()
+				opened++
+				mu.Unlock()
+			} else if !errors.Is(err, ErrAlreadyOpen) {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			c.Close()
+		}()
+		go func() {
+			defer wg.Done()
+			if f, err := c.Snap(); err == nil {
+				f.Release()
+			} else if !errors.Is(err, ErrNotOpen) {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	c.Close()
+	// Every successful Open opened the device once, and all are closed.
+	cams := d.opened()
+	if len(cams) != opened+1 {
+		t.Fatalf("device opened %d times for %d successful Opens", len(cams)-1, opened)
+	}
+	for _, cam := range cams {
+		if !cam.isClosed() {
+			t.Fatal("camera not closed")
+		}
+	}
+}
+
+func TestConcurrentSnapClose(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		c := NewSnapper()
+		openFake(t, c, nil)
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					f, err := c.Snap()
+					if err != nil {
+						if !errors.Is(err, ErrNotOpen) {
+							t.Error(err)
+						}
+						return
+					}
+					f.At(0, 0)
+					f.Release()
+				}
+			}()
+		}
+		c.Close()
+		wg.Wait()
+		if c.Outstanding() != 0 {
+			t.Fatalf("Outstanding: got %d, want 0", c.Outstanding())
+		}
+	}
+}
+
+func TestCloseStalled(t *testing.T) {
+	c := NewSnapper()
+	c.Timeout = 1
+	d := openFake(t, c, func(cam *fakeCamera) {
+		cam.stall = true
+	})
+	start := time.Now()
+	c.Close()
+	if time.Since(start) > time.Duration(c.Timeout)*time.Second+closeGrace {
+		t.Fatalf("Close took %v with a stalled camera", time.Since(start))
+	}
+	if !d.last().isClosed() {
+		t.Fatal("camera not closed")
+	}
+}
+
+func TestCloseHung(t *testing.T) {
+	c := NewSnapper()
+	c.Timeout = 0
+	d := openFake(t, c, func(cam *fakeCamera) {
+		cam.hang = true
+	})
+	start := time.Now()
+	c.Close()
+	// The drain times out, and the camera is stopped to end the wait.
+	if time.Since(start) > 2*closeGrace {
+		t.Fatalf("Close took %v with a hung camera", time.Since(start))
+	}
+	if !d.last().isClosed() {
+		t.Fatal("camera not closed")
+	}
+	select {
+	case err := <-c.Errors():
+		t.Fatalf("error reported by Close: %v", err)
+	default:
+	}
+}
+
+func TestSetField(t *testing.T) {
+	c := NewSnapper()
+	if err := c.SetField(FieldNone); err != nil {
+		t.Fatal(err)
+	}
+	d := openFake(t, c, nil)
+	d.last().mu.Lock()
+	field := d.last().field
+	d.last().mu.Unlock()
+	if field != uint32(FieldNone) {
+		t.Fatalf("field %d set on the camera, want %d", field, FieldNone)
+	}
+	if err := c.SetField(FieldTop); !errors.Is(err, ErrAlreadyOpen) {
+		t.Fatalf("SetField while open: got %v, want ErrAlreadyOpen", err)
+	}
+	// SetField is serialised with Open.
+	c.Close()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			if err := c.SetField(FieldInterlaced); err != nil && !errors.Is(err, ErrAlreadyOpen) {
+				t.Error(err)
+			}
+		}
+	}()
+	if err := c.Open("/dev/fake", "YUYV", 4, 2); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,105 @@
+package snapshot
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Weight given to the latest frame interval when averaging the frame rate.
+const fpsWeight = 0.1
+
+// Stats holds the capture statistics of a Snapper.
+type Stats struct {
+	Captured  uint64    // Frames read from the camera.
+	Dropped   uint64    // Frames discarded because no-one was ready to read them.
+	LastFrame time.Time // Time the last frame was read, or zero if none.
+	FPS       float64   // Average rate that frames are read from the camera.
+	Streaming bool      // True if the camera is open and streaming.
+}
+
+// counters are updated atomically by the capture loop.
+type counters struct {
+	captured  uint64
+	dropped   uint64
+	lastFrame int64  // Unix nanoseconds.
+	interval  uint64 // Average frame interval in nanoseconds, as float64 bits.
+	started   int64  // Unix nanoseconds when streaming started.
+	streaming int32
+}
+
+// frameCaptured updates the counters when a frame is read.
+func (s *counters) frameCaptured(now time.Time) {
+	atomic.AddUint64(&s.captured, 1)
+	t := now.UnixNano()
+	last := atomic.SwapInt64(&s.lastFrame, t)
+	if last == 0 {
+		return
+	}
+	iv := float64(t - last)
+	avg := math.Float64frombits(atomic.LoadUint64(&s.interval))
+	if avg != 0 {
+		iv = avg + (iv-avg)*fpsWeight
+	}
+	atomic.StoreUint64(&s.interval, math.Float64bits(iv))
+}
+
+func (s *counters) frameDropped() {
+	atomic.AddUint64(&s.dropped, 1)
+}
+
+// Stats returns the current capture statistics.
+func (c *Snapper) Stats() Stats {
+	st := Stats{
+		Captured:  atomic.LoadUint64(&c.stats.captured),
+		Dropped:   atomic.LoadUint64(&c.stats.dropped),
+		Streaming: c.IsStreaming(),
+	}
+	if t := atomic.LoadInt64(&c.stats.lastFrame); t != 0 {
+		st.LastFrame = time.Unix(0, t)
+	}
+	if iv := math.Float64frombits(atomic.LoadUint64(&c.stats.interval)); iv > 0 {
+		st.FPS = float64(time.Second) / iv
+	}
+	return st
+}
+
+// QueueDepth returns the number of buffers currently queued with the
+// driver for capture, and the total number of buffers. Buffers are
+// unavailable to the driver while frames are waiting to be read or have
+// not been released, so a falling queued count indicates frames are not
+// being released quickly enough.
+func (c *Snapper) QueueDepth() (queued, total int) {
+	total = int(atomic.LoadInt32(&c.buffers))
+	if !c.IsStreaming() {
+		return 0, total
+	}
+	// Frames from a previous device may still be released
+	// after a reconnect, so limit the count to the valid range.
+	queued = total - int(atomic.LoadInt32(&c.dequeued))
+	if queued < 0 {
+		queued = 0
+	} else if queued > total {
+		queued = total
+	}
+	return queued, total
+}
+
+// FrameAge returns the time since the last frame was read from the camera,
+// or since streaming started if no frame has been read yet.
+// A camera may be streaming but stalled, so a watchdog can use this
+// to detect a camera that has stopped delivering frames.
+func (c *Snapper) FrameAge() time.Duration {
+	t := atomic.LoadInt64(&c.stats.lastFrame)
+	if t == 0 {
+		if t = atomic.LoadInt64(&c.stats.started); t == 0 {
+			return 0
+		}
+	}
+	return time.Since(time.Unix(0, t))
+}
+
+// IsStreaming returns true if the camera is open and streaming.
+func (c *Snapper) IsStreaming() bool {
+	return atomic.LoadInt32(&c.stats.streaming) != 0
+}
@@ -0,0 +1,86 @@
+package snapshot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// CaptureTicker delivers frames at a fixed cadence aligned to the wall clock.
+type CaptureTicker struct {
+	// C delivers a frame snapped at each tick. It is closed when
+	// the ticker is stopped. Frames must be released by the receiver.
+	C <-chan frame.Frame
+
+	c    chan frame.Frame
+	stop chan struct{}
+	once sync.Once
+	mu   sync.Mutex
+	err  error
+}
+
+// Ticker returns a CaptureTicker that snaps a frame at each multiple of
+// interval since the Unix epoch (e.g every 10 seconds on the minute).
+// Unlike a time.Ticker combined with Snap, the ticks do not drift
+// when encoding the frames takes time. If the receiver falls behind,
+// missed ticks are skipped rather than delivered late, and an unread
+// frame is replaced by the newer frame.
+// The ticker stops if a snap fails; the error is returned by Err.
+func (c *Snapper) Ticker(interval time.Duration) *CaptureTicker {
+	ch := make(chan frame.Frame, 1)
+	t := &CaptureTicker{C: ch, c: ch, stop: make(chan struct{})}
+	go t.run(c, interval)
+	return t
+}
+
+// Stop stops the ticker. Any unread frame is released.
+func (t *CaptureTicker) Stop() {
+	t.once.Do(func() {
+		close(t.stop)
+	})
+}
+
+// Err returns the error that stopped the ticker, if any.
+func (t *CaptureTicker) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+func (t *CaptureTicker) run(c *Snapper, interval time.Duration) {
+	defer func() {
+		// Release an unread frame.
+		select {
+		case f := <-t.c:
+			f.Release()
+		default:
+		}
+		close(t.c)
+	}()
+	for {
+		now := time.Now()
+		next := now.Truncate(interval).Add(interval)
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-t.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		f, err := c.snap()
+		if err != nil {
+			t.mu.Lock()
+			t.err = err
+			t.mu.Unlock()
+			return
+		}
+		// Replace any frame that has not been read.
+		select {
+		case old := <-t.c:
+			old.Release()
+		default:
+		}
+		t.c <- f
+	}
+}
@@ -0,0 +1,74 @@
+package snapshot
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/aamcrae/webcam"
+	"golang.org/x/sys/unix"
+)
+
+// SetTimestampSource selects the clock used for frame timestamps.
+// If monotonic is true, Frame.Timestamp returns the monotonic clock time
+// (the time since boot, as an offset from the Unix epoch), which is
+// suitable for correlating frames with other sensors. Otherwise the
+// timestamp is the wall clock time (the default).
+// V4L2 capture drivers choose their own clock (almost always monotonic),
+// so the driver timestamp is converted to the selected clock as required.
+func (c *Snapper) SetTimestampSource(monotonic bool) error {
+	var v int32
+	if monotonic {
+		v = 1
+	}
+	atomic.StoreInt32(&c.monotonic, v)
+	return nil
+}
+
+// useMonotonic returns true if timestamps use the monotonic clock.
+func (c *Snapper) useMonotonic() bool {
+	return atomic.LoadInt32(&c.monotonic) != 0
+}
+
+// timestamp converts the driver timestamp to the selected clock.
+func (c *Snapper) timestamp(info webcam.FrameInfo) time.Time {
+	if info.Timestamp == 0 {
+		return time.Time{}
+	}
+	if info.Monotonic == c.useMonotonic() {
+		return time.Unix(0, int64(info.Timestamp))
+	}
+	offset, err := clockOffset()
+	if err != nil {
+		return time.Time{}
+	}
+	if info.Monotonic {
+		return time.Unix(0, int64(info.Timestamp+offset))
+	}
+	return time.Unix(0, int64(info.Timestamp-offset))
+}
+
+// clockOffset returns the difference between the realtime and monotonic clocks.
+func clockOffset() (time.Duration, error) {
+	var mono, real unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &mono); err != nil {
+		return 0, err
+	}
+	if err := unix.ClockGettime(unix.CLOCK_REALTIME, &real); err != nil {
+		return 0, err
+	}
+	return time.Duration(real.Nano() - mono.Nano()), nil
+}
+
+// ClockOffset returns the offset to add to a frame timestamp to convert it
+// to the wall clock used by time.Now, so that frames can be aligned with
+// other sources (e.g audio) on a shared timeline.
+// With the default wall clock timestamps the offset is zero. With monotonic
+// timestamps (see SetTimestampSource) it is the difference between
+// CLOCK_REALTIME and CLOCK_MONOTONIC, which changes if the system time
+// is adjusted, so it should be sampled close to the frames it is applied to.
+func (c *Snapper) ClockOffset() (time.Duration, error) {
+	if !c.useMonotonic() {
+		return 0, nil
+	}
+	return clockOffset()
+}
@@ -0,0 +1,76 @@
+package snapshot
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// Default thresholds used by IsProducingValidFrames.
+const (
+	defaultValidMinLuminance = 8
+	defaultValidMinStdDev    = 2
+)
+
+// FrameHealth holds the luminance statistics measured by
+// IsProducingValidFrames, averaged over the frames sampled.
+type FrameHealth struct {
+	Samples int     // Number of frames sampled.
+	Mean    float64 // Mean luminance (0-255).
+	StdDev  float64 // Standard deviation of the luminance within a frame.
+}
+
+// IsProducingValidFrames snaps samples frames and checks that they contain
+// a real image, rather than being black (e.g the lens is covered) or a
+// single flat level (e.g the sensor has failed), which a camera may deliver
+// while still streaming. The frames are valid if their mean luminance is
+// at least ValidMinLuminance and the luminance varies across the frame
+// by at least ValidMinStdDev. The measured statistics are also returned.
+func (c *Snapper) IsProducingValidFrames(samples int) (bool, FrameHealth, error) {
+	var h FrameHealth
+	if samples < 1 {
+		return false, h, fmt.Errorf("illegal sample count %d", samples)
+	}
+	for i := 0; i < samples; i++ {
+		f, err := c.Snap()
+		if err != nil {
+			return false, h, err
+		}
+		mean, sd := luminanceStats(f)
+		f.Release()
+		h.Mean += mean
+		h.StdDev += sd
+		h.Samples++
+	}
+	h.Mean /= float64(h.Samples)
+	h.StdDev /= float64(h.Samples)
+	minLum, minSD := c.ValidMinLuminance, c.ValidMinStdDev
+	if minLum == 0 {
+		minLum = defaultValidMinLuminance
+	}
+	if minSD == 0 {
+		minSD = defaultValidMinStdDev
+	}
+	return h.Mean >= minLum && h.StdDev >= minSD, h, nil
+}
+
+// luminanceStats returns the mean and standard deviation of the
+// luminance of the frame.
+func luminanceStats(f frame.Frame) (float64, float64) {
+	g := frame.LuminanceImage(f)
+	b := g.Bounds()
+	n := b.Dx() * b.Dy()
+	if n == 0 {
+		return 0, 0
+	}
+	var sum, sumSq uint64
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for _, l := range g.Pix[g.PixOffset(b.Min.X, y):g.PixOffset(b.Max.X, y)] {
+			sum += uint64(l)
+			sumSq += uint64(l) * uint64(l)
+		}
+	}
+	mean := float64(sum) / float64(n)
+	return mean, math.Sqrt(math.Max(float64(sumSq)/float64(n)-mean*mean, 0))
+}
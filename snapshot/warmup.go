@@ -0,0 +1,46 @@
+package snapshot
+
+import (
+	"time"
+)
+
+// Warmup reads and discards n frames. Many cameras deliver dark or
+// miscoloured frames immediately after streaming starts, while the
+// auto exposure and white balance settle.
+// If WarmupFrames is set, Open calls Warmup before returning.
+func (c *Snapper) Warmup(n int) error {
+	for i := 0; i < n; i++ {
+		f, err := c.snap()
+		if err != nil {
+			return err
+		}
+		f.Release()
+	}
+	return nil
+}
+
+// Idle keeps the camera streaming for the duration, releasing every frame
+// captured, e.g to let auto exposure converge or the sensor temperature
+// stabilise without the caller reading frames. The frame waiting in the
+// stream is also released, so the next Snap returns a frame captured
+// after Idle returns.
+func (c *Snapper) Idle(d time.Duration) error {
+	stream, err := c.getStream()
+	if err != nil {
+		return err
+	}
+	defer c.doneStream()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		select {
+		case s, ok := <-stream:
+			if !ok {
+				return c.streamErr()
+			}
+			c.release(s)
+		case <-timer.C:
+			return nil
+		}
+	}
+}
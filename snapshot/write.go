@@ -0,0 +1,16 @@
+package snapshot
+
+import (
+	"io"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// WriteFrame writes the raw frame data to w. For compressed formats
+// (e.g H264, MJPG) this streams the frames to a file or muxer without
+// decoding or re-encoding. H264 frames are written as the NAL units
+// delivered by the camera, which form an Annex B byte stream.
+func (c *Snapper) WriteFrame(w io.Writer, f frame.Frame) error {
+	_, err := w.Write(frame.RawOf(f))
+	return err
+}
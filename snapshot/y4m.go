@@ -0,0 +1,58 @@
+package snapshot
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/aamcrae/webcam/frame"
+)
+
+// Frame rate written to the Y4M header, since the actual
+// camera frame rate is not known.
+const y4mFrameRate = "30:1"
+
+// RecordY4M writes a YUV4MPEG2 stream of frames to w until the context
+// is cancelled or an error occurs. The stream uses 4:2:0 chroma, and can be
+// read directly by tools such as ffmpeg.
+// The frame size is taken from the first frame.
+func (c *Snapper) RecordY4M(ctx context.Context, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	header := false
+	for {
+		select {
+		case <-ctx.Done():
+			return bw.Flush()
+		default:
+		}
+		f, err := c.Snap()
+		if err != nil {
+			return err
+		}
+		img := frame.ToYCbCr(f)
+		f.Release()
+		if !header {
+			sz := img.Rect.Size()
+			fmt.Fprintf(bw, "YUV4MPEG2 W%d H%d F%s Ip A1:1 C420jpeg\n", sz.X, sz.Y, y4mFrameRate)
+			header = true
+		}
+		if err := writeY4MFrame(bw, img); err != nil {
+			return err
+		}
+	}
+}
+
+// writeY4MFrame writes a single frame as planar Y, Cb and Cr data.
+func writeY4MFrame(w io.Writer, img *image.YCbCr) error {
+	if _, err := io.WriteString(w, "FRAME\n"); err != nil {
+		return err
+	}
+	for _, p := range [][]byte{img.Y, img.Cb, img.Cr} {
+		if _, err := w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
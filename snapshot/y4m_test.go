@@ -0,0 +1,40 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRecordY4M(t *testing.T) {
+	c := NewSnapper()
+	openFake(t, c, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var buf bytes.Buffer
+	if err := c.RecordY4M(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.Bytes()
+	const header = "YUV4MPEG2 W4 H2 F30:1 Ip A1:1 C420jpeg\n"
+	if !bytes.HasPrefix(out, []byte(header)) {
+		t.Fatalf("header: got %q, want %q", out[:bytes.IndexByte(out, '\n')+1], header)
+	}
+	// Each frame is the frame marker, then the 4x2 luma plane and
+	// the 2x1 chroma planes.
+	const frameSize = len("FRAME\n") + 4*2 + 2*2
+	frames := out[len(header):]
+	if len(frames) == 0 || len(frames)%frameSize != 0 {
+		t.Fatalf("got %d bytes of frames, want a multiple of %d", len(frames), frameSize)
+	}
+	first := frames[:frameSize]
+	if !bytes.HasPrefix(first, []byte("FRAME\n")) {
+		t.Fatalf("first frame starts %q", first[:6])
+	}
+	// The fake fills each frame with its sequence number.
+	data := first[6:]
+	if data[0] == 0 || !bytes.Equal(data, bytes.Repeat(data[:1], len(data))) {
+		t.Fatalf("first frame data %v", data)
+	}
+}
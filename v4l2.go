@@ -3,6 +3,9 @@ package webcam
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
+	"runtime"
+	"time"
 	"unsafe"
 
 	"github.com/aamcrae/webcam/ioctl"
@@ -23,16 +26,87 @@ type control struct {
 	c_type controlType
 	min    int32
 	max    int32
+	step   int32
+	def    int32
+	flags  uint32
 }
 
 const (
 	V4L2_CAP_VIDEO_CAPTURE      uint32 = 0x00000001
 	V4L2_CAP_STREAMING          uint32 = 0x04000000
+	V4L2_CAP_TIMEPERFRAME       uint32 = 0x00001000
 	V4L2_BUF_TYPE_VIDEO_CAPTURE uint32 = 1
 	V4L2_MEMORY_MMAP            uint32 = 1
+	V4L2_MEMORY_USERPTR         uint32 = 2
 	V4L2_FIELD_ANY              uint32 = 0
 )
 
+const (
+	V4L2_FIELD_NONE          uint32 = 1
+	V4L2_FIELD_TOP           uint32 = 2
+	V4L2_FIELD_BOTTOM        uint32 = 3
+	V4L2_FIELD_INTERLACED    uint32 = 4
+	V4L2_FIELD_SEQ_TB        uint32 = 5
+	V4L2_FIELD_SEQ_BT        uint32 = 6
+	V4L2_FIELD_ALTERNATE     uint32 = 7
+	V4L2_FIELD_INTERLACED_TB uint32 = 8
+	V4L2_FIELD_INTERLACED_BT uint32 = 9
+)
+
+// Values for the colorimetry fields of the pixel format.
+const (
+	V4L2_COLORSPACE_DEFAULT   uint32 = 0
+	V4L2_COLORSPACE_SMPTE170M uint32 = 1
+	V4L2_COLORSPACE_REC709    uint32 = 3
+	V4L2_COLORSPACE_JPEG      uint32 = 7
+	V4L2_COLORSPACE_SRGB      uint32 = 8
+	V4L2_COLORSPACE_BT2020    uint32 = 10
+	V4L2_COLORSPACE_RAW       uint32 = 11
+
+	V4L2_QUANTIZATION_DEFAULT    uint32 = 0
+	V4L2_QUANTIZATION_FULL_RANGE uint32 = 1
+	V4L2_QUANTIZATION_LIM_RANGE  uint32 = 2
+
+	V4L2_XFER_FUNC_DEFAULT uint32 = 0
+	V4L2_XFER_FUNC_709     uint32 = 1
+	V4L2_XFER_FUNC_SRGB    uint32 = 2
+	V4L2_XFER_FUNC_NONE    uint32 = 5
+
+	// Set in the pixel format flags to request the colorimetry.
+	V4L2_PIX_FMT_FLAG_SET_CSC uint32 = 0x00000002
+)
+
+const (
+	V4L2_BUF_FLAG_TIMESTAMP_MASK      uint32 = 0x0000e000
+	V4L2_BUF_FLAG_TIMESTAMP_UNKNOWN   uint32 = 0x00000000
+	V4L2_BUF_FLAG_TIMESTAMP_MONOTONIC uint32 = 0x00002000
+	V4L2_BUF_FLAG_TIMESTAMP_COPY      uint32 = 0x00004000
+)
+
+const (
+	V4L2_INPUT_TYPE_TUNER  uint32 = 1
+	V4L2_INPUT_TYPE_CAMERA uint32 = 2
+	V4L2_INPUT_TYPE_TOUCH  uint32 = 3
+
+	V4L2_IN_ST_NO_POWER  uint32 = 0x00000001
+	V4L2_IN_ST_NO_SIGNAL uint32 = 0x00000002
+	V4L2_IN_ST_NO_COLOR  uint32 = 0x00000004
+)
+
+const (
+	V4L2_STD_PAL   uint64 = 0x000000ff
+	V4L2_STD_PAL_M uint64 = 0x00000100
+	V4L2_STD_PAL_N uint64 = 0x00000200
+	V4L2_STD_NTSC  uint64 = 0x0000b000
+	V4L2_STD_SECAM uint64 = 0x00ff0000
+)
+
+const (
+	V4L2_FRMIVAL_TYPE_DISCRETE   uint32 = 1
+	V4L2_FRMIVAL_TYPE_CONTINUOUS uint32 = 2
+	V4L2_FRMIVAL_TYPE_STEPWISE   uint32 = 3
+)
+
 const (
 	V4L2_FRMSIZE_TYPE_DISCRETE   uint32 = 1
 	V4L2_FRMSIZE_TYPE_CONTINUOUS uint32 = 2
@@ -40,9 +114,54 @@ const (
 )
 
 const (
-	V4L2_CID_BASE               uint32 = 0x00980900
-	V4L2_CID_AUTO_WHITE_BALANCE uint32 = V4L2_CID_BASE + 12
-	V4L2_CID_PRIVATE_BASE       uint32 = 0x08000000
+	V4L2_CID_BASE                      uint32 = 0x00980900
+	V4L2_CID_AUTO_WHITE_BALANCE        uint32 = V4L2_CID_BASE + 12
+	V4L2_CID_GAMMA                     uint32 = V4L2_CID_BASE + 16
+	V4L2_CID_GAIN                      uint32 = V4L2_CID_BASE + 19
+	V4L2_CID_POWER_LINE_FREQUENCY      uint32 = V4L2_CID_BASE + 24
+	V4L2_CID_WHITE_BALANCE_TEMPERATURE uint32 = V4L2_CID_BASE + 26
+	V4L2_CID_SHARPNESS                 uint32 = V4L2_CID_BASE + 27
+	V4L2_CID_BACKLIGHT_COMPENSATION    uint32 = V4L2_CID_BASE + 28
+	V4L2_CID_PRIVATE_BASE              uint32 = 0x08000000
+
+	V4L2_CID_CAMERA_CLASS_BASE      uint32 = 0x009a0900
+	V4L2_CID_EXPOSURE_AUTO          uint32 = V4L2_CID_CAMERA_CLASS_BASE + 1
+	V4L2_CID_EXPOSURE_ABSOLUTE      uint32 = V4L2_CID_CAMERA_CLASS_BASE + 2
+	V4L2_CID_PAN_ABSOLUTE           uint32 = V4L2_CID_CAMERA_CLASS_BASE + 8
+	V4L2_CID_TILT_ABSOLUTE          uint32 = V4L2_CID_CAMERA_CLASS_BASE + 9
+	V4L2_CID_ZOOM_ABSOLUTE          uint32 = V4L2_CID_CAMERA_CLASS_BASE + 13
+	V4L2_CID_ZOOM_RELATIVE          uint32 = V4L2_CID_CAMERA_CLASS_BASE + 14
+	V4L2_CID_CAMERA_ORIENTATION     uint32 = V4L2_CID_CAMERA_CLASS_BASE + 34
+	V4L2_CID_CAMERA_SENSOR_ROTATION uint32 = V4L2_CID_CAMERA_CLASS_BASE + 35
+
+	V4L2_CID_CAMERA_UVC_BASE             uint32 = V4L2_CID_CAMERA_CLASS_BASE + 0x1000
+	V4L2_CID_UVC_REGION_OF_INTEREST_RECT uint32 = V4L2_CID_CAMERA_UVC_BASE + 1
+	V4L2_CID_UVC_REGION_OF_INTEREST_AUTO uint32 = V4L2_CID_CAMERA_UVC_BASE + 2
+)
+
+// Values for V4L2_CID_EXPOSURE_AUTO.
+const (
+	V4L2_EXPOSURE_AUTO              int32 = 0
+	V4L2_EXPOSURE_MANUAL            int32 = 1
+	V4L2_EXPOSURE_SHUTTER_PRIORITY  int32 = 2
+	V4L2_EXPOSURE_APERTURE_PRIORITY int32 = 3
+)
+
+// Values for V4L2_CID_POWER_LINE_FREQUENCY.
+const (
+	V4L2_CID_POWER_LINE_FREQUENCY_DISABLED int32 = 0
+	V4L2_CID_POWER_LINE_FREQUENCY_50HZ     int32 = 1
+	V4L2_CID_POWER_LINE_FREQUENCY_60HZ     int32 = 2
+	V4L2_CID_POWER_LINE_FREQUENCY_AUTO     int32 = 3
+)
+
+// Bits of V4L2_CID_UVC_REGION_OF_INTEREST_AUTO, selecting the auto
+// controls that use the region of interest.
+const (
+	V4L2_UVC_REGION_OF_INTEREST_AUTO_EXPOSURE      int32 = 1 << 0
+	V4L2_UVC_REGION_OF_INTEREST_AUTO_IRIS          int32 = 1 << 1
+	V4L2_UVC_REGION_OF_INTEREST_AUTO_WHITE_BALANCE int32 = 1 << 2
+	V4L2_UVC_REGION_OF_INTEREST_AUTO_FOCUS         int32 = 1 << 3
 )
 
 const (
@@ -64,26 +183,41 @@ const (
 
 const (
 	V4L2_CTRL_FLAG_DISABLED  uint32 = 0x00000001
+	V4L2_CTRL_FLAG_READ_ONLY uint32 = 0x00000004
 	V4L2_CTRL_FLAG_NEXT_CTRL uint32 = 0x80000000
 )
 
 var (
 	VIDIOC_QUERYCAP  = ioctl.IoR(uintptr('V'), 0, unsafe.Sizeof(v4l2_capability{}))
 	VIDIOC_ENUM_FMT  = ioctl.IoRW(uintptr('V'), 2, unsafe.Sizeof(v4l2_fmtdesc{}))
+	VIDIOC_G_FMT     = ioctl.IoRW(uintptr('V'), 4, unsafe.Sizeof(v4l2_format{}))
 	VIDIOC_S_FMT     = ioctl.IoRW(uintptr('V'), 5, unsafe.Sizeof(v4l2_format{}))
 	VIDIOC_REQBUFS   = ioctl.IoRW(uintptr('V'), 8, unsafe.Sizeof(v4l2_requestbuffers{}))
 	VIDIOC_QUERYBUF  = ioctl.IoRW(uintptr('V'), 9, unsafe.Sizeof(v4l2_buffer{}))
 	VIDIOC_QBUF      = ioctl.IoRW(uintptr('V'), 15, unsafe.Sizeof(v4l2_buffer{}))
 	VIDIOC_DQBUF     = ioctl.IoRW(uintptr('V'), 17, unsafe.Sizeof(v4l2_buffer{}))
+	VIDIOC_G_PARM    = ioctl.IoRW(uintptr('V'), 21, unsafe.Sizeof(v4l2_streamparm{}))
+	VIDIOC_S_PARM    = ioctl.IoRW(uintptr('V'), 22, unsafe.Sizeof(v4l2_streamparm{}))
+	VIDIOC_G_STD     = ioctl.IoR(uintptr('V'), 23, 8)
+	VIDIOC_S_STD     = ioctl.IoW(uintptr('V'), 24, 8)
+	VIDIOC_ENUMSTD   = ioctl.IoRW(uintptr('V'), 25, unsafe.Sizeof(v4l2_standard{}))
+	VIDIOC_ENUMINPUT = ioctl.IoRW(uintptr('V'), 26, unsafe.Sizeof(v4l2_input{}))
 	VIDIOC_G_CTRL    = ioctl.IoRW(uintptr('V'), 27, unsafe.Sizeof(v4l2_control{}))
 	VIDIOC_S_CTRL    = ioctl.IoRW(uintptr('V'), 28, unsafe.Sizeof(v4l2_control{}))
 	VIDIOC_QUERYCTRL = ioctl.IoRW(uintptr('V'), 36, unsafe.Sizeof(v4l2_queryctrl{}))
+	VIDIOC_QUERYMENU = ioctl.IoRW(uintptr('V'), 37, unsafe.Sizeof(v4l2_querymenu{}))
 	//sizeof int32
-	VIDIOC_STREAMON        = ioctl.IoW(uintptr('V'), 18, 4)
-	VIDIOC_STREAMOFF       = ioctl.IoW(uintptr('V'), 19, 4)
-	VIDIOC_ENUM_FRAMESIZES = ioctl.IoRW(uintptr('V'), 74, unsafe.Sizeof(v4l2_frmsizeenum{}))
-	__p                    = unsafe.Pointer(uintptr(0))
-	NativeByteOrder        = getNativeByteOrder()
+	VIDIOC_STREAMON            = ioctl.IoW(uintptr('V'), 18, 4)
+	VIDIOC_STREAMOFF           = ioctl.IoW(uintptr('V'), 19, 4)
+	VIDIOC_G_INPUT             = ioctl.IoR(uintptr('V'), 38, 4)
+	VIDIOC_S_INPUT             = ioctl.IoRW(uintptr('V'), 39, 4)
+	VIDIOC_CROPCAP             = ioctl.IoRW(uintptr('V'), 58, unsafe.Sizeof(v4l2_cropcap{}))
+	VIDIOC_G_EXT_CTRLS         = ioctl.IoRW(uintptr('V'), 71, unsafe.Sizeof(v4l2_ext_controls{}))
+	VIDIOC_S_EXT_CTRLS         = ioctl.IoRW(uintptr('V'), 72, unsafe.Sizeof(v4l2_ext_controls{}))
+	VIDIOC_ENUM_FRAMESIZES     = ioctl.IoRW(uintptr('V'), 74, unsafe.Sizeof(v4l2_frmsizeenum{}))
+	VIDIOC_ENUM_FRAMEINTERVALS = ioctl.IoRW(uintptr('V'), 75, unsafe.Sizeof(v4l2_frmivalenum{}))
+	__p                        unsafe.Pointer
+	NativeByteOrder            = getNativeByteOrder()
 )
 
 type v4l2_capability struct {
@@ -96,6 +230,41 @@ type v4l2_capability struct {
 	reserved     [3]uint32
 }
 
+type v4l2_rect struct {
+	left   int32
+	top    int32
+	width  uint32
+	height uint32
+}
+
+type v4l2_cropcap struct {
+	_type       uint32
+	bounds      v4l2_rect
+	defrect     v4l2_rect
+	pixelaspect v4l2_fract
+}
+
+type v4l2_standard struct {
+	index       uint32
+	id          uint64
+	name        [24]uint8
+	frameperiod v4l2_fract
+	framelines  uint32
+	reserved    [4]uint32
+}
+
+type v4l2_input struct {
+	index        uint32
+	name         [32]uint8
+	_type        uint32
+	audioset     uint32
+	tuner        uint32
+	std          uint64
+	status       uint32
+	capabilities uint32
+	reserved     [3]uint32
+}
+
 type v4l2_fmtdesc struct {
 	index       uint32
 	_type       uint32
@@ -198,11 +367,64 @@ type v4l2_queryctrl struct {
 	reserved      [2]uint32
 }
 
+// The union of the name and integer value is stored as the name.
+type v4l2_querymenu struct {
+	id       uint32
+	index    uint32
+	name     [32]uint8
+	reserved uint32
+}
+
+type v4l2_frmivalenum struct {
+	index        uint32
+	pixel_format uint32
+	width        uint32
+	height       uint32
+	_type        uint32
+	union        [24]uint8
+	reserved     [2]uint32
+}
+
 type v4l2_control struct {
 	id    uint32
 	value int32
 }
 
+type v4l2_fract struct {
+	numerator   uint32
+	denominator uint32
+}
+
+type v4l2_captureparm struct {
+	capability   uint32
+	capturemode  uint32
+	timeperframe v4l2_fract
+	extendedmode uint32
+	readbuffers  uint32
+	reserved     [4]uint32
+}
+
+type v4l2_streamparm struct {
+	_type   uint32
+	capture v4l2_captureparm
+	pad     [200 - unsafe.Sizeof(v4l2_captureparm{})]uint8
+}
+
+type v4l2_ext_controls struct {
+	which      uint32
+	count      uint32
+	error_idx  uint32
+	request_fd int32
+	reserved   [1]uint32
+	controls   unsafe.Pointer
+}
+
+// struct v4l2_ext_control is packed, so it is encoded as bytes:
+// id (4), size (4), reserved2 (4), value (8).
+const v4l2_ext_control_size = 20
+
+const V4L2_CTRL_WHICH_CUR_VAL uint32 = 0
+
 func checkCapabilities(fd uintptr) (supportsVideoCapture bool, supportsVideoStreaming bool, err error) {
 
 	caps := &v4l2_capability{}
@@ -288,7 +510,7 @@ func getFrameSize(fd uintptr, index uint32, code uint32) (frameSize FrameSize, e
 	return
 }
 
-func setImageFormat(fd uintptr, formatcode, width, height, stride, size *uint32) (err error) {
+func setImageFormat(fd uintptr, field uint32, csc *Colorimetry, formatcode, width, height, stride, size *uint32) (err error) {
 
 	format := &v4l2_format{
 		_type: V4L2_BUF_TYPE_VIDEO_CAPTURE,
@@ -298,7 +520,13 @@ func setImageFormat(fd uintptr, formatcode, width, height, stride, size *uint32)
 		Width:       *width,
 		Height:      *height,
 		Pixelformat: *formatcode,
-		Field:       V4L2_FIELD_ANY,
+		Field:       field,
+	}
+	if *csc != (Colorimetry{}) {
+		pix.Colorspace = csc.Colorspace
+		pix.Quantization = csc.Quantization
+		pix.Xfer_func = csc.XferFunc
+		pix.Flags = V4L2_PIX_FMT_FLAG_SET_CSC
 	}
 
 	pixbytes := &bytes.Buffer{}
@@ -328,17 +556,36 @@ func setImageFormat(fd uintptr, formatcode, width, height, stride, size *uint32)
 	*formatcode = pixReverse.Pixelformat
 	*stride = pixReverse.Bytesperline
 	*size = pixReverse.Sizeimage
+	csc.Colorspace = pixReverse.Colorspace
+	csc.Quantization = pixReverse.Quantization
+	csc.XferFunc = pixReverse.Xfer_func
 
 	return
 
 }
 
-func mmapRequestBuffers(fd uintptr, buf_count *uint32) (err error) {
+func getImageFormat(fd uintptr) (formatcode, width, height uint32, err error) {
+	format := &v4l2_format{
+		_type: V4L2_BUF_TYPE_VIDEO_CAPTURE,
+	}
+	err = ioctl.Ioctl(fd, VIDIOC_G_FMT, uintptr(unsafe.Pointer(format)))
+	if err != nil {
+		return
+	}
+	pix := &v4l2_pix_format{}
+	err = binary.Read(bytes.NewBuffer(format.union.data[:]), NativeByteOrder, pix)
+	if err != nil {
+		return
+	}
+	return pix.Pixelformat, pix.Width, pix.Height, nil
+}
+
+func requestBuffers(fd uintptr, memory uint32, buf_count *uint32) (err error) {
 
 	req := &v4l2_requestbuffers{}
 	req.count = *buf_count
 	req._type = V4L2_BUF_TYPE_VIDEO_CAPTURE
-	req.memory = V4L2_MEMORY_MMAP
+	req.memory = memory
 
 	err = ioctl.Ioctl(fd, VIDIOC_REQBUFS, uintptr(unsafe.Pointer(req)))
 
@@ -379,12 +626,12 @@ func mmapQueryBuffer(fd uintptr, index uint32, length *uint32) (buffer []byte, e
 	return
 }
 
-func mmapDequeueBuffer(fd uintptr, index *uint32, length *uint32) (err error) {
+func dequeueBuffer(fd uintptr, memory uint32, index *uint32, length *uint32, info *FrameInfo) (err error) {
 
 	buffer := &v4l2_buffer{}
 
 	buffer._type = V4L2_BUF_TYPE_VIDEO_CAPTURE
-	buffer.memory = V4L2_MEMORY_MMAP
+	buffer.memory = memory
 
 	err = ioctl.Ioctl(fd, VIDIOC_DQBUF, uintptr(unsafe.Pointer(buffer)))
 
@@ -394,6 +641,9 @@ func mmapDequeueBuffer(fd uintptr, index *uint32, length *uint32) (err error) {
 
 	*index = buffer.index
 	*length = buffer.bytesused
+	info.Timestamp = time.Duration(buffer.timestamp.Nano())
+	info.Monotonic = (buffer.flags & V4L2_BUF_FLAG_TIMESTAMP_MASK) == V4L2_BUF_FLAG_TIMESTAMP_MONOTONIC
+	info.Sequence = buffer.sequence
 
 	return
 
@@ -412,6 +662,31 @@ func mmapEnqueueBuffer(fd uintptr, index uint32) (err error) {
 
 }
 
+// userptrAllocBuffer allocates page aligned memory for a user pointer buffer.
+func userptrAllocBuffer(length uint32) ([]byte, error) {
+	return unix.Mmap(-1, 0, int(length), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS)
+}
+
+func userptrEnqueueBuffer(fd uintptr, index uint32, buf []byte) (err error) {
+
+	buffer := &v4l2_buffer{}
+
+	buffer._type = V4L2_BUF_TYPE_VIDEO_CAPTURE
+	buffer.memory = V4L2_MEMORY_USERPTR
+	buffer.index = index
+	buffer.length = uint32(len(buf))
+	ptr := uint64(uintptr(unsafe.Pointer(&buf[0])))
+	if len(buffer.union) == 8 {
+		NativeByteOrder.PutUint64(buffer.union[:], ptr)
+	} else {
+		NativeByteOrder.PutUint32(buffer.union[:], uint32(ptr))
+	}
+
+	err = ioctl.Ioctl(fd, VIDIOC_QBUF, uintptr(unsafe.Pointer(buffer)))
+	return
+
+}
+
 func mmapReleaseBuffer(buffer []byte) (err error) {
 	err = unix.Munmap(buffer)
 	return
@@ -473,6 +748,206 @@ func setControl(fd uintptr, id uint32, val int32) error {
 	return ioctl.Ioctl(fd, VIDIOC_S_CTRL, uintptr(unsafe.Pointer(ctrl)))
 }
 
+// extControls gets or sets several controls in a single ioctl.
+// When getting, the values are updated in place.
+func extControls(fd uintptr, op uintptr, ids []uint32, values []int32) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	buf := make([]byte, len(ids)*v4l2_ext_control_size)
+	for i, id := range ids {
+		c := buf[i*v4l2_ext_control_size:]
+		NativeByteOrder.PutUint32(c[0:], id)
+		NativeByteOrder.PutUint32(c[12:], uint32(values[i]))
+	}
+	ctrls := &v4l2_ext_controls{
+		which:    V4L2_CTRL_WHICH_CUR_VAL,
+		count:    uint32(len(ids)),
+		controls: unsafe.Pointer(&buf[0]),
+	}
+	err := ioctl.Ioctl(fd, op, uintptr(unsafe.Pointer(ctrls)))
+	runtime.KeepAlive(buf)
+	if err != nil {
+		return err
+	}
+	for i := range values {
+		values[i] = int32(NativeByteOrder.Uint32(buf[i*v4l2_ext_control_size+12:]))
+	}
+	return nil
+}
+
+// rectControl gets or sets a compound control holding a v4l2_rect.
+func rectControl(fd uintptr, op uintptr, id uint32, rect *v4l2_rect) error {
+	buf := make([]byte, v4l2_ext_control_size)
+	payload := &v4l2_rect{}
+	*payload = *rect
+	NativeByteOrder.PutUint32(buf[0:], id)
+	NativeByteOrder.PutUint32(buf[4:], uint32(unsafe.Sizeof(*payload)))
+	// The union holds a pointer to the payload for compound controls.
+	*(*uintptr)(unsafe.Pointer(&buf[12])) = uintptr(unsafe.Pointer(payload))
+	ctrls := &v4l2_ext_controls{
+		which:    V4L2_CTRL_WHICH_CUR_VAL,
+		count:    1,
+		controls: unsafe.Pointer(&buf[0]),
+	}
+	err := ioctl.Ioctl(fd, op, uintptr(unsafe.Pointer(ctrls)))
+	runtime.KeepAlive(buf)
+	runtime.KeepAlive(payload)
+	if err != nil {
+		return err
+	}
+	*rect = *payload
+	return nil
+}
+
+func getExtControls(fd uintptr, ids []uint32, values []int32) error {
+	return extControls(fd, VIDIOC_G_EXT_CTRLS, ids, values)
+}
+
+func setExtControls(fd uintptr, ids []uint32, values []int32) error {
+	return extControls(fd, VIDIOC_S_EXT_CTRLS, ids, values)
+}
+
+func getFramerate(fd uintptr) (num, denom uint32, err error) {
+	param := &v4l2_streamparm{}
+	param._type = V4L2_BUF_TYPE_VIDEO_CAPTURE
+
+	err = ioctl.Ioctl(fd, VIDIOC_G_PARM, uintptr(unsafe.Pointer(param)))
+	if err != nil {
+		return
+	}
+	// The time per frame is the inverse of the frame rate.
+	num = param.capture.timeperframe.denominator
+	denom = param.capture.timeperframe.numerator
+	return
+}
+
+func setFramerate(fd uintptr, num, denom uint32) error {
+	param := &v4l2_streamparm{}
+	param._type = V4L2_BUF_TYPE_VIDEO_CAPTURE
+
+	err := ioctl.Ioctl(fd, VIDIOC_G_PARM, uintptr(unsafe.Pointer(param)))
+	if err != nil {
+		return err
+	}
+	if param.capture.capability&V4L2_CAP_TIMEPERFRAME == 0 {
+		return errors.New("Frame rate cannot be set")
+	}
+	param.capture.timeperframe.numerator = denom
+	param.capture.timeperframe.denominator = num
+	return ioctl.Ioctl(fd, VIDIOC_S_PARM, uintptr(unsafe.Pointer(param)))
+}
+
+func getInput(fd uintptr, index uint32) (input Input, err error) {
+	in := &v4l2_input{}
+	in.index = index
+	err = ioctl.Ioctl(fd, VIDIOC_ENUMINPUT, uintptr(unsafe.Pointer(in)))
+	if err != nil {
+		return
+	}
+	input.Index = in.index
+	input.Name = CToGoString(in.name[:])
+	input.Type = in._type
+	input.Status = in.status
+	return
+}
+
+func getCapability(fd uintptr) (c Capability, err error) {
+	caps := &v4l2_capability{}
+	err = ioctl.Ioctl(fd, VIDIOC_QUERYCAP, uintptr(unsafe.Pointer(caps)))
+	if err != nil {
+		return
+	}
+	c.Driver = CToGoString(caps.driver[:])
+	c.Card = CToGoString(caps.card[:])
+	c.BusInfo = CToGoString(caps.bus_info[:])
+	c.Version = caps.version
+	c.Capabilities = caps.capabilities
+	c.DeviceCaps = caps.device_caps
+	return
+}
+
+// getFrameInterval returns the frame interval(s) at the index. Discrete
+// intervals return a single interval, stepwise intervals the minimum and maximum.
+func getFrameInterval(fd uintptr, index, code, width, height uint32) (interval []v4l2_fract, err error) {
+	ival := &v4l2_frmivalenum{}
+	ival.index = index
+	ival.pixel_format = code
+	ival.width = width
+	ival.height = height
+	err = ioctl.Ioctl(fd, VIDIOC_ENUM_FRAMEINTERVALS, uintptr(unsafe.Pointer(ival)))
+	if err != nil {
+		return
+	}
+	fract := func(i int) v4l2_fract {
+		return v4l2_fract{NativeByteOrder.Uint32(ival.union[i*8:]), NativeByteOrder.Uint32(ival.union[i*8+4:])}
+	}
+	switch ival._type {
+	case V4L2_FRMIVAL_TYPE_DISCRETE:
+		interval = []v4l2_fract{fract(0)}
+	case V4L2_FRMIVAL_TYPE_CONTINUOUS, V4L2_FRMIVAL_TYPE_STEPWISE:
+		interval = []v4l2_fract{fract(0), fract(1)}
+	}
+	return
+}
+
+func getMenuItem(fd uintptr, id, index uint32) (string, error) {
+	menu := &v4l2_querymenu{}
+	menu.id = id
+	menu.index = index
+	err := ioctl.Ioctl(fd, VIDIOC_QUERYMENU, uintptr(unsafe.Pointer(menu)))
+	if err != nil {
+		return "", err
+	}
+	return CToGoString(menu.name[:]), nil
+}
+
+func getPixelAspect(fd uintptr) (num, denom uint32, err error) {
+	cropcap := &v4l2_cropcap{}
+	cropcap._type = V4L2_BUF_TYPE_VIDEO_CAPTURE
+	err = ioctl.Ioctl(fd, VIDIOC_CROPCAP, uintptr(unsafe.Pointer(cropcap)))
+	if err != nil {
+		return
+	}
+	return cropcap.pixelaspect.numerator, cropcap.pixelaspect.denominator, nil
+}
+
+func getStandard(fd uintptr, index uint32) (std Standard, err error) {
+	s := &v4l2_standard{}
+	s.index = index
+	err = ioctl.Ioctl(fd, VIDIOC_ENUMSTD, uintptr(unsafe.Pointer(s)))
+	if err != nil {
+		return
+	}
+	std.ID = s.id
+	std.Name = CToGoString(s.name[:])
+	std.FrameNum = s.frameperiod.numerator
+	std.FrameDenom = s.frameperiod.denominator
+	std.Lines = s.framelines
+	return
+}
+
+func getCurrentStandard(fd uintptr) (uint64, error) {
+	var id uint64
+	err := ioctl.Ioctl(fd, VIDIOC_G_STD, uintptr(unsafe.Pointer(&id)))
+	return id, err
+}
+
+func setStandard(fd uintptr, id uint64) error {
+	return ioctl.Ioctl(fd, VIDIOC_S_STD, uintptr(unsafe.Pointer(&id)))
+}
+
+func getCurrentInput(fd uintptr) (uint32, error) {
+	var index int32
+	err := ioctl.Ioctl(fd, VIDIOC_G_INPUT, uintptr(unsafe.Pointer(&index)))
+	return uint32(index), err
+}
+
+func setInput(fd uintptr, index uint32) error {
+	i := int32(index)
+	return ioctl.Ioctl(fd, VIDIOC_S_INPUT, uintptr(unsafe.Pointer(&i)))
+}
+
 func queryControls(fd uintptr) []control {
 	controls := []control{}
 	var err error
@@ -503,6 +978,9 @@ func queryControls(fd uintptr) []control {
 			c.name = CToGoString(query.name[:])
 			c.min = query.minimum
 			c.max = query.maximum
+			c.step = query.step
+			c.def = query.default_value
+			c.flags = query.flags
 			controls = append(controls, c)
 		}
 	}
@@ -5,6 +5,10 @@ package webcam
 
 import (
 	"errors"
+	"fmt"
+	"image"
+	"time"
+
 	"golang.org/x/sys/unix"
 )
 
@@ -14,14 +18,77 @@ type Webcam struct {
 	bufcount  uint32
 	buffers   [][]byte
 	streaming bool
+	field     uint32
+	csc       Colorimetry // Colorimetry requested.
+	gotCsc    Colorimetry // Colorimetry selected by the driver.
+	memory    uint32
+	size      uint32
+}
+
+// Metadata for a frame, as reported by the driver.
+type FrameInfo struct {
+	// Time the frame was captured, relative to the epoch of the clock.
+	Timestamp time.Duration
+	// True if the timestamp is from the monotonic clock (most drivers),
+	// otherwise it is from the realtime (wall) clock.
+	Monotonic bool
+	// Sequence number of the frame, as counted by the driver.
+	Sequence uint32
+}
+
+// Colorimetry of the frame data, as V4L2_COLORSPACE_*, V4L2_QUANTIZATION_*
+// and V4L2_XFER_FUNC_* values. Zero values are the driver defaults.
+type Colorimetry struct {
+	Colorspace   uint32
+	Quantization uint32
+	XferFunc     uint32
 }
 
 type ControlID uint32
 
 type Control struct {
+	Name    string
+	Min     int32
+	Max     int32
+	Step    int32
+	Default int32
+	// Menu is true if the control is a menu (see GetControlMenu).
+	Menu bool
+	// ReadOnly is true if the control cannot be set.
+	ReadOnly bool
+}
+
+// Device capabilities, as reported by the driver.
+type Capability struct {
+	Driver  string
+	Card    string
+	BusInfo string
+	Version uint32
+	// Capabilities of the physical device, and of this device node.
+	Capabilities uint32
+	DeviceCaps   uint32
+}
+
+// A video input, such as one connector of a capture card.
+type Input struct {
+	Index uint32
+	Name  string
+	// Type is V4L2_INPUT_TYPE_TUNER, V4L2_INPUT_TYPE_CAMERA etc.
+	Type uint32
+	// Status is a set of V4L2_IN_ST flags, e.g V4L2_IN_ST_NO_SIGNAL.
+	// It is only valid for the current input.
+	Status uint32
+}
+
+// An analog video standard, such as PAL or NTSC.
+type Standard struct {
+	// ID is a set of V4L2_STD flags.
+	ID   uint64
 	Name string
-	Min  int32
-	Max  int32
+	// The frame period is FrameNum/FrameDenom seconds.
+	FrameNum   uint32
+	FrameDenom uint32
+	Lines      uint32
 }
 
 // Open a webcam with a given path
@@ -53,6 +120,7 @@ func Open(path string) (*Webcam, error) {
 	w := new(Webcam)
 	w.fd = uintptr(fd)
 	w.bufcount = 256
+	w.memory = V4L2_MEMORY_MMAP
 	return w, nil
 }
 
@@ -115,15 +183,179 @@ func (w *Webcam) SetImageFormat(f PixelFormat, width, height uint32) (PixelForma
 	var stride uint32
 	var size uint32
 
-	err := setImageFormat(w.fd, &code, &width, &height, &stride, &size)
+	csc := w.csc
+	err := setImageFormat(w.fd, w.field, &csc, &code, &width, &height, &stride, &size)
 
 	if err != nil {
 		return 0, 0, 0, 0, 0, err
 	} else {
+		w.size = size
+		w.gotCsc = csc
 		return PixelFormat(code), cw, ch, stride, size, nil
 	}
 }
 
+// Get the current image format and frame size. Before a format has been
+// set, this is the driver's default, usually the camera's native format.
+func (w *Webcam) GetImageFormat() (PixelFormat, uint32, uint32, error) {
+	code, width, height, err := getImageFormat(w.fd)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return PixelFormat(code), width, height, nil
+}
+
+// Set the field order (one of the V4L2_FIELD_* values) used
+// in subsequent calls to SetImageFormat.
+// The default is V4L2_FIELD_ANY, which lets the driver choose.
+func (w *Webcam) SetField(field uint32) {
+	w.field = field
+}
+
+// Set the colorimetry requested in subsequent calls to SetImageFormat.
+// Drivers that cannot convert the colorimetry ignore the request, so
+// the result should be checked with GetColorimetry.
+// The default (all zero) leaves the choice to the driver.
+func (w *Webcam) SetColorimetry(csc Colorimetry) {
+	w.csc = csc
+}
+
+// Get the colorimetry reported by the driver in the last SetImageFormat.
+func (w *Webcam) GetColorimetry() Colorimetry {
+	return w.gotCsc
+}
+
+// Set the streaming I/O method, either V4L2_MEMORY_MMAP (the default)
+// or V4L2_MEMORY_USERPTR.
+// With V4L2_MEMORY_MMAP the frame buffers are allocated by the driver,
+// and with V4L2_MEMORY_USERPTR the buffers are allocated by this package
+// in application memory. Not all drivers support V4L2_MEMORY_USERPTR.
+// Not allowed if streaming is already on.
+func (w *Webcam) SetIOMethod(memory uint32) error {
+	if w.streaming {
+		return errors.New("Cannot set I/O method when streaming")
+	}
+	if memory != V4L2_MEMORY_MMAP && memory != V4L2_MEMORY_USERPTR {
+		return errors.New("Unsupported I/O method")
+	}
+	w.memory = memory
+	return nil
+}
+
+// Returns the video inputs of the device.
+func (w *Webcam) GetInputs() ([]Input, error) {
+	var inputs []Input
+	for index := uint32(0); ; index++ {
+		input, err := getInput(w.fd, index)
+		if err != nil {
+			// The end of the list is reported as EINVAL.
+			if index > 0 && err == unix.EINVAL {
+				return inputs, nil
+			}
+			return nil, err
+		}
+		inputs = append(inputs, input)
+	}
+}
+
+// Returns the index of the current input.
+func (w *Webcam) GetInput() (uint32, error) {
+	return getCurrentInput(w.fd)
+}
+
+// Select the video input.
+func (w *Webcam) SetInput(index uint32) error {
+	return setInput(w.fd, index)
+}
+
+// Returns the capabilities of the device.
+func (w *Webcam) GetCapability() (Capability, error) {
+	return getCapability(w.fd)
+}
+
+// Returns the frame rates supported for the format and frame size, in
+// frames per second. For stepwise or continuous intervals, the
+// minimum and maximum rates are returned.
+func (w *Webcam) GetFrameRates(f PixelFormat, width, height uint32) []float64 {
+	var rates []float64
+	for index := uint32(0); ; index++ {
+		intervals, err := getFrameInterval(w.fd, index, uint32(f), width, height)
+		if err != nil {
+			return rates
+		}
+		for _, i := range intervals {
+			if i.numerator != 0 {
+				rates = append(rates, float64(i.denominator)/float64(i.numerator))
+			}
+		}
+		if len(intervals) != 1 {
+			// Stepwise intervals have a single entry.
+			return rates
+		}
+	}
+}
+
+// Returns the pixel aspect ratio (height / width of a pixel) as num/denom.
+// Square pixels have a ratio of 1/1.
+func (w *Webcam) GetPixelAspect() (uint32, uint32, error) {
+	num, denom, err := getPixelAspect(w.fd)
+	if err != nil {
+		return 0, 0, err
+	}
+	if num == 0 || denom == 0 {
+		return 0, 0, errors.New("Pixel aspect not reported by driver")
+	}
+	return num, denom, nil
+}
+
+// Returns the video standards supported by the current input.
+// Devices without analog inputs return an error.
+func (w *Webcam) GetStandards() ([]Standard, error) {
+	var stds []Standard
+	for index := uint32(0); ; index++ {
+		std, err := getStandard(w.fd, index)
+		if err != nil {
+			if index > 0 && err == unix.EINVAL {
+				return stds, nil
+			}
+			return nil, err
+		}
+		stds = append(stds, std)
+	}
+}
+
+// Returns the current video standard.
+func (w *Webcam) GetStandard() (uint64, error) {
+	return getCurrentStandard(w.fd)
+}
+
+// Select the video standard.
+func (w *Webcam) SetStandard(id uint64) error {
+	return setStandard(w.fd, id)
+}
+
+// Get the current frame rate, in frames per second.
+func (w *Webcam) GetFramerate() (float64, error) {
+	num, denom, err := getFramerate(w.fd)
+	if err != nil {
+		return 0, err
+	}
+	if denom == 0 {
+		return 0, errors.New("Frame rate not reported by driver")
+	}
+	return float64(num) / float64(denom), nil
+}
+
+// Request a frame rate, in frames per second. The driver may
+// select a different rate, which can be read using GetFramerate.
+func (w *Webcam) SetFramerate(fps float64) error {
+	if fps <= 0 {
+		return errors.New("Illegal frame rate")
+	}
+	// Express the rate as a fraction so that rates such as 29.97 are kept.
+	return setFramerate(w.fd, uint32(fps*1000+0.5), 1000)
+}
+
 // Set the number of frames to be buffered.
 // Not allowed if streaming is already on.
 func (w *Webcam) SetBufferCount(count uint32) error {
@@ -134,15 +366,38 @@ func (w *Webcam) SetBufferCount(count uint32) error {
 	return nil
 }
 
+// Get the number of frame buffers. Once streaming has started, this is
+// the number allocated by the driver, which may differ from the number set.
+func (w *Webcam) GetBufferCount() uint32 {
+	return w.bufcount
+}
+
 // Get a map of available controls.
 func (w *Webcam) GetControls() map[ControlID]Control {
 	cmap := make(map[ControlID]Control)
 	for _, c := range queryControls(w.fd) {
-		cmap[ControlID(c.id)] = Control{c.name, c.min, c.max}
+		cmap[ControlID(c.id)] = Control{c.name, c.min, c.max, c.step, c.def, c.c_type == c_menu,
+			c.flags&V4L2_CTRL_FLAG_READ_ONLY != 0}
 	}
 	return cmap
 }
 
+// Returns the names of the items of a menu control, indexed by value.
+// Drivers may omit values within the control's range.
+func (w *Webcam) GetControlMenu(id ControlID) (map[int32]string, error) {
+	c, ok := w.GetControls()[id]
+	if !ok || !c.Menu {
+		return nil, errors.New("Not a menu control")
+	}
+	menu := make(map[int32]string)
+	for i := c.Min; i <= c.Max; i++ {
+		if name, err := getMenuItem(w.fd, uint32(id), uint32(i)); err == nil {
+			menu[i] = name
+		}
+	}
+	return menu, nil
+}
+
 // Get the value of a control.
 func (w *Webcam) GetControl(id ControlID) (int32, error) {
 	return getControl(w.fd, uint32(id))
@@ -153,23 +408,83 @@ func (w *Webcam) SetControl(id ControlID, value int32) error {
 	return setControl(w.fd, uint32(id), value)
 }
 
+// Get the values of several controls in a single request.
+func (w *Webcam) GetExtControls(ids []ControlID) (map[ControlID]int32, error) {
+	cids := make([]uint32, len(ids))
+	for i, id := range ids {
+		cids[i] = uint32(id)
+	}
+	values := make([]int32, len(ids))
+	if err := getExtControls(w.fd, cids, values); err != nil {
+		return nil, err
+	}
+	result := make(map[ControlID]int32)
+	for i, id := range ids {
+		result[id] = values[i]
+	}
+	return result, nil
+}
+
+// Set several controls in a single request. The driver applies
+// all of the values, or none of them if any are invalid.
+func (w *Webcam) SetExtControls(controls map[ControlID]int32) error {
+	var ids []uint32
+	var values []int32
+	for id, v := range controls {
+		ids = append(ids, uint32(id))
+		values = append(values, v)
+	}
+	return setExtControls(w.fd, ids, values)
+}
+
+// Get a rectangle control, such as V4L2_CID_UVC_REGION_OF_INTEREST_RECT.
+func (w *Webcam) GetRectControl(id ControlID) (image.Rectangle, error) {
+	var r v4l2_rect
+	if err := rectControl(w.fd, VIDIOC_G_EXT_CTRLS, uint32(id), &r); err != nil {
+		return image.Rectangle{}, err
+	}
+	return image.Rect(int(r.left), int(r.top), int(r.left)+int(r.width), int(r.top)+int(r.height)), nil
+}
+
+// Set a rectangle control, such as V4L2_CID_UVC_REGION_OF_INTEREST_RECT.
+// The driver may adjust the rectangle, and the value set is returned.
+func (w *Webcam) SetRectControl(id ControlID, rect image.Rectangle) (image.Rectangle, error) {
+	r := v4l2_rect{left: int32(rect.Min.X), top: int32(rect.Min.Y), width: uint32(rect.Dx()), height: uint32(rect.Dy())}
+	if err := rectControl(w.fd, VIDIOC_S_EXT_CTRLS, uint32(id), &r); err != nil {
+		return image.Rectangle{}, err
+	}
+	return image.Rect(int(r.left), int(r.top), int(r.left)+int(r.width), int(r.top)+int(r.height)), nil
+}
+
 // Start streaming process
 func (w *Webcam) StartStreaming() error {
 	if w.streaming {
 		return errors.New("Already streaming")
 	}
 
-	err := mmapRequestBuffers(w.fd, &w.bufcount)
+	if w.memory == V4L2_MEMORY_USERPTR && w.size == 0 {
+		return errors.New("Image format must be set before using the user pointer I/O method")
+	}
+
+	err := requestBuffers(w.fd, w.memory, &w.bufcount)
 
 	if err != nil {
-		return errors.New("Failed to map request buffers: " + string(err.Error()))
+		if w.memory == V4L2_MEMORY_USERPTR {
+			return errors.New("Device does not support the user pointer I/O method: " + string(err.Error()))
+		}
+		return fmt.Errorf("Failed to map request buffers: %w", err)
 	}
 
 	w.buffers = make([][]byte, w.bufcount, w.bufcount)
 	for index, _ := range w.buffers {
 		var length uint32
+		var buffer []byte
 
-		buffer, err := mmapQueryBuffer(w.fd, uint32(index), &length)
+		if w.memory == V4L2_MEMORY_USERPTR {
+			buffer, err = userptrAllocBuffer(w.size)
+		} else {
+			buffer, err = mmapQueryBuffer(w.fd, uint32(index), &length)
+		}
 
 		if err != nil {
 			return errors.New("Failed to map memory: " + string(err.Error()))
@@ -180,7 +495,7 @@ func (w *Webcam) StartStreaming() error {
 
 	for index, _ := range w.buffers {
 
-		err := mmapEnqueueBuffer(w.fd, uint32(index))
+		err := w.ReleaseFrame(uint32(index))
 
 		if err != nil {
 			return errors.New("Failed to enqueue buffer: " + string(err.Error()))
@@ -191,7 +506,7 @@ func (w *Webcam) StartStreaming() error {
 	err = startStreaming(w.fd)
 
 	if err != nil {
-		return errors.New("Failed to start streaming: " + string(err.Error()))
+		return fmt.Errorf("Failed to start streaming: %w", err)
 	}
 	w.streaming = true
 
@@ -214,21 +529,32 @@ func (w *Webcam) ReadFrame() ([]byte, error) {
 // If frame cannot be read at the moment
 // function will return empty slice
 func (w *Webcam) GetFrame() ([]byte, uint32, error) {
+	frame, index, _, err := w.GetFrameInfo()
+	return frame, index, err
+}
+
+// Get a single frame from the webcam, as per GetFrame, and also
+// return the metadata for the frame.
+func (w *Webcam) GetFrameInfo() ([]byte, uint32, FrameInfo, error) {
 	var index uint32
 	var length uint32
+	var info FrameInfo
 
-	err := mmapDequeueBuffer(w.fd, &index, &length)
+	err := dequeueBuffer(w.fd, w.memory, &index, &length, &info)
 
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, info, err
 	}
 
-	return w.buffers[int(index)][:length], index, nil
+	return w.buffers[int(index)][:length], index, info, nil
 
 }
 
 // Release the frame buffer that was obtained via GetFrame
 func (w *Webcam) ReleaseFrame(index uint32) error {
+	if w.memory == V4L2_MEMORY_USERPTR {
+		return userptrEnqueueBuffer(w.fd, index, w.buffers[index])
+	}
 	return mmapEnqueueBuffer(w.fd, index)
 }
 
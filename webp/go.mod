@@ -0,0 +1,10 @@
+module github.com/aamcrae/webcam/webp
+
+go 1.16
+
+require (
+	github.com/aamcrae/webcam v0.0.0
+	github.com/chai2010/webp v1.4.0
+)
+
+replace github.com/aamcrae/webcam => ../
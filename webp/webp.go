@@ -0,0 +1,26 @@
+// webp encodes images as WebP.
+//
+// The encoder is github.com/chai2010/webp, which wraps libwebp using cgo,
+// since there is no pure Go lossy WebP encoder. To avoid the cgo
+// dependency for users that do not need WebP, this package is a
+// separate module.
+package webp
+
+import (
+	"image"
+	"io"
+
+	"github.com/aamcrae/webcam/frame"
+	libwebp "github.com/chai2010/webp"
+)
+
+// Encode writes the image as a lossy WebP, with quality from 0 to 100.
+// WebP files are usually considerably smaller than JPEG at the same quality.
+func Encode(w io.Writer, img image.Image, quality int) error {
+	if quality < 0 {
+		quality = 0
+	} else if quality > 100 {
+		quality = 100
+	}
+	return libwebp.Encode(w, frame.AsDrawSource(img), &libwebp.Options{Quality: float32(quality)})
+}